@@ -0,0 +1,116 @@
+package image
+
+import (
+	"fmt"
+	goimage "image"
+	"path"
+	"path/filepath"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/util"
+	"github.com/fogleman/gg"
+)
+
+// AssetDir is the local directory with cached track maps and car logos
+var AssetDir = "public/assets"
+
+// Enrichment holds the optional track and car details to be drawn into image headers
+type Enrichment struct {
+	TrackConfig bool           // add the track config to the track title
+	TrackMap    bool           // draw a track map thumbnail
+	CarLogos    bool           // draw the logos of all cars
+	Cars        []database.Car // cars of the raceweek, for car logos
+}
+
+// Variants returns the image file variants for the enrichment options, none if disabled
+func (e Enrichment) Variants() []string {
+	variants := make([]string, 0)
+	if e.TrackConfig {
+		variants = append(variants, "config")
+	}
+	if e.TrackMap {
+		variants = append(variants, "map")
+	}
+	if e.CarLogos {
+		variants = append(variants, "logos")
+	}
+	return variants
+}
+
+// TrackTitle returns the name of the track, including its config if enabled
+func (e Enrichment) TrackTitle(track database.Track) string {
+	if e.TrackConfig && len(track.Config) > 0 {
+		return fmt.Sprintf("%s - %s", track.Name, track.Config)
+	}
+	return track.Name
+}
+
+// DrawAssets draws the track map and car logos right-aligned into a header, returns the width used
+func (e Enrichment) DrawAssets(dc *gg.Context, track database.Track, xRight, y, height float64) float64 {
+	xPos := xRight
+	size := height - 4
+
+	if e.CarLogos {
+		for c := len(e.Cars) - 1; c >= 0; c-- {
+			car := e.Cars[c]
+			logo, ok := LoadAsset("cars", car.CarID, car.LogoImage)
+			if !ok {
+				// fallback to the cars abbreviation
+				if len(car.Abbreviation) == 0 {
+					continue
+				}
+				width, _ := dc.MeasureString(car.Abbreviation)
+				xPos -= width + 4
+				dc.DrawStringAnchored(car.Abbreviation, xPos+width/2, y+height/2, 0.5, 0.5)
+				continue
+			}
+			xPos -= drawScaled(dc, logo, xPos, y+2, size) + 2
+		}
+	}
+
+	if e.TrackMap {
+		if trackMap, ok := LoadAsset("tracks", track.TrackID, track.MapImage); ok {
+			xPos -= drawScaled(dc, trackMap, xPos, y+2, size) + 2
+		}
+	}
+	return xRight - xPos
+}
+
+// LoadAsset loads a locally cached asset image, either by ID or by the filename of its original image URL
+func LoadAsset(kind string, id int, original string) (goimage.Image, bool) {
+	candidates := []string{filepath.Join(AssetDir, kind, fmt.Sprintf("%d.png", id))}
+	if len(original) > 0 {
+		candidates = append(candidates, filepath.Join(AssetDir, kind, path.Base(original)))
+	}
+	for _, filename := range candidates {
+		if !util.FileExists(filename) {
+			continue
+		}
+		img, err := gg.LoadImage(filename)
+		if err != nil {
+			log.Errorf("could not load asset [%s]: %v", filename, err)
+			continue
+		}
+		return img, true
+	}
+	log.Debugf("could not find any %s asset for [%d]", kind, id)
+	return nil, false
+}
+
+// drawScaled draws an image scaled to a height, right-aligned to x, returns the scaled width
+func drawScaled(dc *gg.Context, img goimage.Image, xRight, y, height float64) float64 {
+	bounds := img.Bounds()
+	if bounds.Dy() == 0 {
+		return 0
+	}
+	scale := height / float64(bounds.Dy())
+	width := float64(bounds.Dx()) * scale
+
+	dc.Push()
+	dc.Translate(xRight-width, y)
+	dc.Scale(scale, scale)
+	dc.DrawImage(img, 0, 0)
+	dc.Pop()
+	return width
+}
@@ -0,0 +1,295 @@
+package clubs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	clubsDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_club_rankings_drawn_total",
+		Help: "Total club rankings drawn by iRvisualizer.",
+	})
+)
+
+type DataRow struct {
+	Club    string
+	Value   string
+	Drivers []DataDriver // top contributing drivers
+	Marked  bool
+}
+
+type DataDriver struct {
+	Driver string
+	Value  string
+}
+
+type Clubs struct {
+	ColorScheme  string
+	Variants     []string
+	Season       database.Season
+	Week         database.RaceWeek
+	Track        database.Track
+	Enrichment   image.Enrichment // track and car details in the header
+	Data         []DataRow
+	BorderSize   float64
+	FooterHeight float64
+	ImageHeight  float64
+	ImageWidth   float64
+	HeaderHeight float64
+	ClubHeight   float64
+	DriverHeight float64
+	PaddingSize  float64
+	Rows         float64
+}
+
+func New(colorScheme string, season database.Season, week database.RaceWeek, track database.Track, data []DataRow) Clubs {
+	clubs := Clubs{
+		ColorScheme:  colorScheme,
+		Season:       season,
+		Week:         week,
+		Track:        track,
+		Data:         data,
+		BorderSize:   float64(2),
+		FooterHeight: float64(14),
+		ImageWidth:   float64(640),
+		HeaderHeight: float64(24),
+		ClubHeight:   float64(18),
+		DriverHeight: float64(14),
+		PaddingSize:  float64(3),
+		Rows:         float64(len(data)),
+	}
+	clubs.ImageHeight = clubs.Rows*(clubs.ClubHeight+clubs.DriverHeight) + clubs.ClubHeight + clubs.HeaderHeight + clubs.PaddingSize*3
+	return clubs
+}
+
+func IsAvailable(colorScheme string, seasonID, week int, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "clubs", seasonID, week, "", variants...)
+}
+
+func Filename(seasonID, week int, variants ...string) string {
+	return image.ImageFilename("clubs", seasonID, week, "", variants...)
+}
+
+func (c *Clubs) Filename() string {
+	return Filename(c.Season.SeasonID, c.Week.RaceWeek+1, c.Variants...)
+}
+
+func (c *Clubs) Draw() error {
+	clubsDraws.Inc()
+
+	// club ranking title, season + week
+	clubsTitle := fmt.Sprintf("%s - Club Standings", c.Season.SeasonName)
+	if len(c.Season.SeasonName) > 64 {
+		clubsTitle = c.Season.SeasonName
+	}
+	clubsWeekTitle := "Season"
+	if c.Week.RaceWeek >= 0 {
+		clubsWeekTitle = fmt.Sprintf("Week %d - %s", c.Week.RaceWeek+1, c.Enrichment.TrackTitle(c.Track))
+	}
+
+	log.Infof("draw club ranking for [%s] - [%s]", clubsTitle, clubsWeekTitle)
+
+	// colorizer
+	if len(c.ColorScheme) == 0 {
+		c.ColorScheme = c.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(c.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(c.ImageWidth), int(c.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, c.ImageWidth, c.HeaderHeight)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(c.ImageWidth/1.6, 0, c.ImageWidth-c.ImageWidth/1.6, c.HeaderHeight)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw club ranking title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(clubsTitle, c.ImageWidth/3.2, c.HeaderHeight/2, 0.5, 0.5)
+	// draw track map and car logos, if enabled
+	assetsWidth := float64(0)
+	if c.Week.RaceWeek >= 0 {
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		assetsWidth = c.Enrichment.DrawAssets(dc, c.Track, c.ImageWidth-c.PaddingSize, 0, c.HeaderHeight)
+	}
+	// draw week title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	weekTitleWidth := c.ImageWidth - c.ImageWidth/1.6 - assetsWidth
+	if width, _ := dc.MeasureString(clubsWeekTitle); width > weekTitleWidth-c.PaddingSize*2 {
+		if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+	}
+	dc.DrawStringAnchored(clubsWeekTitle, c.ImageWidth/1.6+weekTitleWidth/2, c.HeaderHeight/2, 0.5, 0.5)
+
+	// adjust to header height
+	yPosColumnHeaderStart := c.HeaderHeight + c.PaddingSize
+
+	// draw the column header
+	xLength := c.ImageWidth - c.PaddingSize*2
+	xPos := c.PaddingSize
+	yPos := yPosColumnHeaderStart
+
+	dc.DrawRectangle(xPos, yPos, xLength, c.ClubHeight)
+	color.TopNHeaderBG(dc)
+	dc.Fill()
+
+	color.TopNHeaderFG(dc)
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	dc.DrawStringAnchored("Club Points", xPos+xLength/2, yPos+c.ClubHeight/2, 0.5, 0.5)
+
+	// draw outline
+	color.TopNHeaderOutline(dc)
+	dc.DrawRectangle(xPos, yPos, xLength, c.ClubHeight)
+	dc.SetLineWidth(1)
+	dc.Stroke()
+
+	// draw the rows
+	yPosColumnStart := yPosColumnHeaderStart + c.ClubHeight + c.PaddingSize
+	rowHeight := c.ClubHeight + c.DriverHeight
+	var previousValue string
+	for d, data := range c.Data {
+		yPos := yPosColumnStart + float64(d)*rowHeight
+
+		// zebra pattern
+		dc.DrawRectangle(xPos, yPos, xLength, rowHeight)
+		if d%2 == 0 {
+			color.TopNCellDarkerBG(dc)
+		} else {
+			color.TopNCellLighterBG(dc)
+		}
+		// marked club?
+		if data.Marked {
+			color.TopNHeaderBG(dc)
+		}
+		dc.Fill()
+
+		// position
+		color.TopNCellPosition(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		if data.Value != previousValue {
+			previousValue = data.Value
+			// draw trophies
+			if d <= 2 {
+				// load icon
+				iconColor := "gold"
+				if d == 1 {
+					iconColor = "silver"
+				}
+				if d == 2 {
+					iconColor = "bronze"
+				}
+				icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/trophy_%s.png", iconColor))
+				if err != nil {
+					return fmt.Errorf("could not load icon: %v", err)
+				}
+				dc.DrawImage(icon, int(xPos+c.PaddingSize), int(yPos+1))
+			} else {
+				dc.DrawStringAnchored(fmt.Sprintf("%d.", d+1), xPos+c.PaddingSize*2, yPos+c.ClubHeight/2, 0, 0.5)
+			}
+		}
+		// club name
+		color.TopNCellDriver(dc)
+		// marked club?
+		if data.Marked {
+			color.TopNHeaderFG(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.Club, xPos+20+c.PaddingSize*2, yPos+c.ClubHeight/2, 0, 0.5)
+
+		// top contributing drivers
+		drivers := make([]string, 0)
+		for _, driver := range data.Drivers {
+			drivers = append(drivers, fmt.Sprintf("%s (%s)", driver.Driver, driver.Value))
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		// shorten list of drivers if it doesn't fit into the row
+		driverList := strings.Join(drivers, ", ")
+		for len(drivers) > 1 {
+			if width, _ := dc.MeasureString(driverList); width < xLength-80-c.PaddingSize*4 {
+				break
+			}
+			drivers = drivers[:len(drivers)-1]
+			driverList = strings.Join(drivers, ", ") + ", ..."
+		}
+		dc.DrawStringAnchored(driverList, xPos+20+c.PaddingSize*2, yPos+c.ClubHeight+c.DriverHeight/2-2, 0, 0.5)
+
+		// value
+		color.TopNCellValue(dc)
+		// marked club?
+		if data.Marked {
+			color.TopNHeaderFGDanger(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 14); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.Value, xPos+xLength-c.PaddingSize*2, yPos+rowHeight/2, 1, 0.5)
+
+		// draw outline
+		color.TopNCellOutline(dc)
+		dc.DrawRectangle(xPos, yPos, xLength, rowHeight)
+		dc.SetLineWidth(0.5)
+		dc.Stroke()
+	}
+
+	// add border to image
+	bdc := gg.NewContext(int(c.ImageWidth+c.BorderSize*2), int(c.ImageHeight+c.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(c.BorderSize), int(c.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(c.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := c.Week.LastUpdate.UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-c.FooterHeight/2, float64(bdc.Height())+c.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", c.FooterHeight/2, float64(bdc.Height())+c.FooterHeight/2, 0, 0.5)
+
+	if err := c.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(c.Filename()) // finally write to file
+}
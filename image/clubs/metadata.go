@@ -0,0 +1,22 @@
+package clubs
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (c *Clubs) MetadataFilename() string {
+	return image.MetadataFilename("clubs", c.Season.SeasonID, c.Week.RaceWeek+1, "", c.Variants...)
+}
+
+func (c *Clubs) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(c.MetadataFilename())
+}
+
+func (c *Clubs) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(c.ColorScheme, "clubs",
+		c.Season.SeasonID, c.Week.RaceWeek+1,
+		c.Season.SeasonName, c.Season.Year, c.Season.Quarter,
+		c.Track.Name, "", c.Season.StartDate, c.Variants...,
+	)
+}
@@ -0,0 +1,116 @@
+package color
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// Stop is a color at a position (0.0 - 1.0) of a gradient
+type Stop struct {
+	Position   float64
+	R, G, B, A int
+}
+
+// Gradient is a color scale with multiple color stops, interpolated linearly between them
+type Gradient []Stop
+
+var gradients = map[string]Gradient{
+	"spectral": { // blue -> green -> yellow -> red
+		{Position: 0, R: 49, G: 54, B: 149, A: 255},
+		{Position: 0.33, R: 26, G: 152, B: 80, A: 255},
+		{Position: 0.66, R: 254, G: 224, B: 57, A: 255},
+		{Position: 1, R: 215, G: 48, B: 39, A: 255},
+	},
+	"viridis": { // purple -> teal -> yellow
+		{Position: 0, R: 68, G: 1, B: 84, A: 255},
+		{Position: 0.5, R: 33, G: 145, B: 140, A: 255},
+		{Position: 1, R: 253, G: 231, B: 37, A: 255},
+	},
+	"heat": { // transparent -> yellow -> red
+		{Position: 0, R: 255, G: 237, B: 160, A: 20},
+		{Position: 0.5, R: 254, G: 178, B: 76, A: 255},
+		{Position: 1, R: 189, G: 0, B: 38, A: 255},
+	},
+}
+
+// GetGradient returns either a predefined gradient by name, or parses a list of comma separated hex colors into evenly spaced stops
+func GetGradient(value string) (Gradient, error) {
+	if gradient, ok := gradients[strings.ToLower(value)]; ok {
+		return gradient, nil
+	}
+
+	colors := strings.Split(value, ",")
+	if len(colors) < 2 {
+		return nil, fmt.Errorf("invalid gradient [%s], must be a predefined gradient or at least 2 hex colors", value)
+	}
+	gradient := make(Gradient, 0, len(colors))
+	for c, hex := range colors {
+		stop, err := parseHex(hex)
+		if err != nil {
+			return nil, err
+		}
+		stop.Position = float64(c) / float64(len(colors)-1)
+		gradient = append(gradient, stop)
+	}
+	return gradient, nil
+}
+
+func parseHex(value string) (Stop, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 6 {
+		hex += "ff" // fully opaque
+	}
+	if len(hex) != 8 {
+		return Stop{}, fmt.Errorf("invalid hex color [%s]", value)
+	}
+	rgba, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Stop{}, fmt.Errorf("invalid hex color [%s]: %v", value, err)
+	}
+	return Stop{
+		R: int(rgba >> 24 & 0xff),
+		G: int(rgba >> 16 & 0xff),
+		B: int(rgba >> 8 & 0xff),
+		A: int(rgba & 0xff),
+	}, nil
+}
+
+// At returns the interpolated color at a position (0.0 - 1.0) of the gradient
+func (g Gradient) At(position float64) Stop {
+	if len(g) == 0 {
+		return Stop{}
+	}
+	stops := make(Gradient, len(g))
+	copy(stops, g)
+	sort.SliceStable(stops, func(i, j int) bool {
+		return stops[i].Position < stops[j].Position
+	})
+
+	if position <= stops[0].Position {
+		return stops[0]
+	}
+	for s := 1; s < len(stops); s++ {
+		if position <= stops[s].Position {
+			from, to := stops[s-1], stops[s]
+			ratio := (position - from.Position) / (to.Position - from.Position)
+			return Stop{
+				Position: position,
+				R:        from.R + int(float64(to.R-from.R)*ratio),
+				G:        from.G + int(float64(to.G-from.G)*ratio),
+				B:        from.B + int(float64(to.B-from.B)*ratio),
+				A:        from.A + int(float64(to.A-from.A)*ratio),
+			}
+		}
+	}
+	return stops[len(stops)-1]
+}
+
+// Set sets the color at a position (0.0 - 1.0) of the gradient
+func (g Gradient) Set(dc *gg.Context, position float64) {
+	stop := g.At(position)
+	dc.SetRGBA255(stop.R, stop.G, stop.B, stop.A)
+}
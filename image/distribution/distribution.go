@@ -0,0 +1,411 @@
+package distribution
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/util"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	distributionDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_distributions_drawn_total",
+		Help: "Total laptime distributions drawn by iRvisualizer.",
+	})
+)
+
+type DataSet struct {
+	Division string
+	Laptimes []database.Laptime
+	Marked   []DataMark
+}
+
+type DataMark struct {
+	Driver  string
+	Laptime database.Laptime
+}
+
+type Distribution struct {
+	ColorScheme         string
+	Team                string
+	Variants            []string
+	Name                string
+	Season              database.Season
+	Week                database.RaceWeek
+	Track               database.Track
+	Enrichment          image.Enrichment // track and car details in the header
+	Data                []DataSet
+	Reference           DataMark
+	BorderSize          float64
+	FooterHeight        float64
+	ImageHeight         float64
+	ImageWidth          float64
+	HeaderHeight        float64
+	ColumnHeaderHeight  float64
+	RowHeight           float64
+	AxisHeight          float64
+	PaddingSize         float64
+	Rows                float64
+	DivisionColumnWidth float64
+}
+
+type boxplot struct {
+	Min, Q1, Median, Q3, Max float64 // whiskers are at min/max within 1.5 IQR
+	Outliers                 []float64
+}
+
+func New(colorScheme, team string, season database.Season, week database.RaceWeek, track database.Track, data []DataSet, reference DataMark) Distribution {
+	dist := Distribution{
+		ColorScheme:         colorScheme,
+		Team:                team,
+		Name:                "distribution",
+		Season:              season,
+		Week:                week,
+		Track:               track,
+		Data:                data,
+		Reference:           reference,
+		BorderSize:          float64(2),
+		FooterHeight:        float64(14),
+		ImageWidth:          float64(756),
+		HeaderHeight:        float64(46),
+		ColumnHeaderHeight:  float64(16),
+		RowHeight:           float64(36),
+		AxisHeight:          float64(18),
+		PaddingSize:         float64(3),
+		Rows:                float64(len(data)),
+		DivisionColumnWidth: float64(74),
+	}
+	dist.ImageHeight = dist.Rows*dist.RowHeight + dist.AxisHeight + dist.ColumnHeaderHeight + dist.HeaderHeight + dist.PaddingSize*3
+	return dist
+}
+
+func IsAvailable(colorScheme string, seasonID, week int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "distribution", seasonID, week, team, variants...)
+}
+
+func Filename(seasonID, week int, team string, variants ...string) string {
+	return image.ImageFilename("distribution", seasonID, week, team, variants...)
+}
+
+func (d *Distribution) Filename() string {
+	return Filename(d.Season.SeasonID, d.Week.RaceWeek+1, d.Team, d.Variants...)
+}
+
+func calculate(laptimes []database.Laptime) boxplot {
+	values := make([]float64, 0)
+	for _, laptime := range laptimes {
+		values = append(values, float64(laptime))
+	}
+	box := boxplot{
+		Q1:     util.Quantile(values, 0.25),
+		Median: util.Median(values),
+		Q3:     util.Quantile(values, 0.75),
+	}
+	iqr := box.Q3 - box.Q1
+	box.Min = math.MaxFloat64
+	box.Max = 0
+	for _, value := range values {
+		if value < box.Q1-1.5*iqr || value > box.Q3+1.5*iqr {
+			box.Outliers = append(box.Outliers, value)
+			continue
+		}
+		if value < box.Min {
+			box.Min = value
+		}
+		if value > box.Max {
+			box.Max = value
+		}
+	}
+	return box
+}
+
+func (d *Distribution) Draw() error {
+	distributionDraws.Inc()
+
+	// distribution titles, season + track
+	distTitle := fmt.Sprintf("%s - Laptime Distribution", d.Season.SeasonName)
+	if len(d.Season.SeasonName) > 64 {
+		distTitle = d.Season.SeasonName
+	}
+	distWeekTitle := fmt.Sprintf("Week %d", d.Week.RaceWeek+1)
+	distTrackTitle := d.Enrichment.TrackTitle(d.Track)
+
+	log.Infof("draw laptime distribution for [%s] - [%s]", distTitle, distTrackTitle)
+
+	// calculate boxplots and the overall laptime range to draw
+	boxes := make([]boxplot, 0)
+	minLap := math.MaxFloat64
+	maxLap := float64(0)
+	for _, data := range d.Data {
+		box := calculate(data.Laptimes)
+		boxes = append(boxes, box)
+		if len(data.Laptimes) == 0 {
+			continue
+		}
+		minLap = math.Min(minLap, box.Min)
+		maxLap = math.Max(maxLap, box.Max)
+		for _, outlier := range box.Outliers {
+			minLap = math.Min(minLap, outlier)
+		}
+	}
+	if d.Reference.Laptime > 0 {
+		minLap = math.Min(minLap, float64(d.Reference.Laptime))
+		maxLap = math.Max(maxLap, float64(d.Reference.Laptime))
+	}
+	if maxLap <= minLap { // nothing to draw, just show a range of 1s
+		minLap = math.Min(minLap, 600000)
+		maxLap = minLap + 10000
+	}
+	// add some space on both ends
+	margin := (maxLap - minLap) * 0.05
+	minLap = minLap - margin
+	maxLap = maxLap + margin
+
+	// colorizer
+	if len(d.ColorScheme) == 0 {
+		d.ColorScheme = d.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(d.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(d.ImageWidth), int(d.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, d.ImageWidth, d.HeaderHeight/2)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(0, d.HeaderHeight/2, d.ImageWidth, d.HeaderHeight/2)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw season title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(distTitle, d.PaddingSize*3, d.HeaderHeight/4, 0, 0.5)
+	// draw week title
+	dc.DrawStringAnchored(distWeekTitle, d.ImageWidth/4, d.HeaderHeight/4*3, 0.5, 0.5)
+	// draw track map and car logos, if enabled
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	assetsWidth := d.Enrichment.DrawAssets(dc, d.Track, d.ImageWidth-d.PaddingSize, d.HeaderHeight/2, d.HeaderHeight/2)
+	// draw track title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(distTrackTitle, d.ImageWidth/3*2-assetsWidth/2, d.HeaderHeight/4*3, 0.5, 0.5)
+
+	// adjust to header height
+	yPosColumnHeaderStart := d.HeaderHeight + d.PaddingSize
+
+	// draw column headers
+	xDivisionLength := d.DivisionColumnWidth - d.PaddingSize*2
+	xPlotStart := d.DivisionColumnWidth
+	xPlotLength := d.ImageWidth - xPlotStart - d.PaddingSize
+	headers := []struct {
+		title   string
+		xPos    float64
+		xLength float64
+	}{
+		{"Division", d.PaddingSize, xDivisionLength},
+		{"Best Race Laps", xPlotStart, xPlotLength},
+	}
+	for _, header := range headers {
+		dc.DrawRectangle(header.xPos, yPosColumnHeaderStart, header.xLength, d.ColumnHeaderHeight)
+		color.TopNHeaderBG(dc)
+		dc.Fill()
+
+		color.TopNHeaderFG(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(header.title, header.xPos+header.xLength/2, yPosColumnHeaderStart+d.ColumnHeaderHeight/2, 0.5, 0.5)
+
+		// draw outline
+		color.TopNHeaderOutline(dc)
+		dc.DrawRectangle(header.xPos, yPosColumnHeaderStart, header.xLength, d.ColumnHeaderHeight)
+		dc.SetLineWidth(1)
+		dc.Stroke()
+	}
+
+	// map a laptime onto the x-axis
+	xLaptime := func(laptime float64) float64 {
+		return xPlotStart + (laptime-minLap)/(maxLap-minLap)*xPlotLength
+	}
+
+	// draw rows
+	yPosRowStart := yPosColumnHeaderStart + d.ColumnHeaderHeight + d.PaddingSize
+	for row, data := range d.Data {
+		box := boxes[row]
+		xPos := d.PaddingSize
+		yPos := yPosRowStart + float64(row)*d.RowHeight
+		xLength := d.ImageWidth - d.PaddingSize*2
+		yMid := yPos + d.RowHeight/2
+
+		// zebra pattern
+		dc.DrawRectangle(xPos, yPos, xLength, d.RowHeight)
+		if row%2 == 0 {
+			color.TopNCellDarkerBG(dc)
+		} else {
+			color.TopNCellLighterBG(dc)
+		}
+		dc.Fill()
+
+		// draw outline
+		color.TopNCellOutline(dc)
+		dc.DrawRectangle(xPos, yPos, xLength, d.RowHeight)
+		dc.SetLineWidth(0.5)
+		dc.Stroke()
+
+		// draw division and number of drivers
+		color.TopNCellPosition(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.Division, xPos+xDivisionLength/2, yMid-d.RowHeight/6, 0.5, 0.5)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(fmt.Sprintf("%d drivers", len(data.Laptimes)), xPos+xDivisionLength/2, yMid+d.RowHeight/5, 0.5, 0.5)
+
+		if len(data.Laptimes) == 0 {
+			continue
+		}
+
+		// whiskers
+		color.TopNCellValue(dc)
+		dc.SetLineWidth(1)
+		dc.DrawLine(xLaptime(box.Min), yMid, xLaptime(box.Q1), yMid)
+		dc.DrawLine(xLaptime(box.Q3), yMid, xLaptime(box.Max), yMid)
+		dc.DrawLine(xLaptime(box.Min), yMid-d.RowHeight/6, xLaptime(box.Min), yMid+d.RowHeight/6)
+		dc.DrawLine(xLaptime(box.Max), yMid-d.RowHeight/6, xLaptime(box.Max), yMid+d.RowHeight/6)
+		dc.Stroke()
+
+		// box
+		boxHeight := d.RowHeight / 2
+		dc.DrawRectangle(xLaptime(box.Q1), yMid-boxHeight/2, xLaptime(box.Q3)-xLaptime(box.Q1), boxHeight)
+		color.TopNHeaderBG(dc)
+		dc.FillPreserve()
+		color.TopNCellValue(dc)
+		dc.SetLineWidth(1)
+		dc.Stroke()
+
+		// median
+		color.TopNCellValueDanger(dc)
+		dc.SetLineWidth(2)
+		dc.DrawLine(xLaptime(box.Median), yMid-boxHeight/2, xLaptime(box.Median), yMid+boxHeight/2)
+		dc.Stroke()
+
+		// outliers
+		color.TopNCellValue(dc)
+		dc.SetLineWidth(1)
+		for _, outlier := range box.Outliers {
+			if outlier > maxLap {
+				continue // way too slow, not worth drawing
+			}
+			dc.DrawCircle(xLaptime(outlier), yMid, 2)
+			dc.Stroke()
+		}
+
+		// marked drivers
+		if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 9); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		for _, mark := range data.Marked {
+			laptime := math.Min(float64(mark.Laptime), maxLap)
+			color.TopNCellValueDanger(dc)
+			dc.MoveTo(xLaptime(laptime), yMid-boxHeight/2-1)
+			dc.LineTo(xLaptime(laptime)-4, yMid-boxHeight/2-7)
+			dc.LineTo(xLaptime(laptime)+4, yMid-boxHeight/2-7)
+			dc.ClosePath()
+			dc.Fill()
+			dc.DrawStringAnchored(mark.Driver, xLaptime(laptime), yMid+boxHeight/2+1, 0.5, 1)
+		}
+	}
+
+	// draw the laptime axis
+	yPosAxis := yPosRowStart + d.Rows*d.RowHeight
+	color.TopNCellPosition(dc)
+	if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	ticks := 6
+	for tick := 0; tick <= ticks; tick++ {
+		laptime := minLap + (maxLap-minLap)/float64(ticks)*float64(tick)
+		xPos := xLaptime(laptime)
+		dc.SetLineWidth(1)
+		dc.DrawLine(xPos, yPosAxis, xPos, yPosAxis+d.PaddingSize)
+		dc.Stroke()
+
+		anchor := 0.5
+		if tick == 0 {
+			anchor = 0
+		}
+		if tick == ticks {
+			anchor = 1
+		}
+		dc.DrawStringAnchored(util.ConvertLaptime(database.Laptime(laptime)), xPos, yPosAxis+d.AxisHeight/2+1, anchor, 0.5)
+	}
+
+	// draw reference line
+	if d.Reference.Laptime > 0 {
+		xPos := xLaptime(float64(d.Reference.Laptime))
+		color.TopNHeaderFGDanger(dc)
+		dc.SetLineWidth(1.5)
+		dc.SetDash(4, 3)
+		dc.DrawLine(xPos, yPosRowStart, xPos, yPosAxis)
+		dc.Stroke()
+		dc.SetDash()
+
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(
+			fmt.Sprintf("%s: %s", d.Reference.Driver, util.ConvertLaptime(d.Reference.Laptime)),
+			xPos+d.PaddingSize, yPosColumnHeaderStart+d.ColumnHeaderHeight/2, 0, 0.5)
+	}
+
+	// add border to image
+	bdc := gg.NewContext(int(d.ImageWidth+d.BorderSize*2), int(d.ImageHeight+d.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(d.BorderSize), int(d.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(d.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := d.Week.LastUpdate.UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-d.FooterHeight/2, float64(bdc.Height())+d.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", d.FooterHeight/2, float64(bdc.Height())+d.FooterHeight/2, 0, 0.5)
+
+	if err := d.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(d.Filename()) // finally write to file
+}
@@ -0,0 +1,22 @@
+package distribution
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (d *Distribution) MetadataFilename() string {
+	return image.MetadataFilename("distribution", d.Season.SeasonID, d.Week.RaceWeek+1, d.Team, d.Variants...)
+}
+
+func (d *Distribution) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(d.MetadataFilename())
+}
+
+func (d *Distribution) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(d.ColorScheme, "distribution",
+		d.Season.SeasonID, d.Week.RaceWeek+1,
+		d.Season.SeasonName, d.Season.Year, d.Season.Quarter,
+		d.Track.Name, d.Team, d.Season.StartDate, d.Variants...,
+	)
+}
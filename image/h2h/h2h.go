@@ -0,0 +1,372 @@
+package h2h
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/util"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	h2hDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_h2h_drawn_total",
+		Help: "Total head-to-head comparisons drawn by iRvisualizer.",
+	})
+)
+
+// MaxDrivers is how many drivers can be compared at once
+const MaxDrivers = 4
+
+// colors of the drivers, same as the first lines of the positions chart
+var palette = [][3]int{
+	{31, 119, 180},
+	{255, 127, 14},
+	{44, 160, 44},
+	{214, 39, 40},
+}
+
+type DataRow struct {
+	Driver        string
+	Races         int
+	Wins          int
+	Laps          int
+	Incidents     int
+	IRatingBefore int                // iRating before the first race of the season
+	IRatingAfter  int                // iRating after the last race of the season
+	ChampPoints   []int              // championship points of each week, -1 if there was no race
+	BestLaps      []database.Laptime // best race lap of each week, 0 if there was none
+	IRatings      []int              // iRating after the last race of each week, 0 if there was no race
+	Ahead         []int              // number of shared races finished ahead of each of the other drivers
+	Shared        []int              // number of shared races with each of the other drivers
+}
+
+type H2H struct {
+	ColorScheme  string
+	Team         string
+	Variants     []string
+	Season       database.Season
+	Weeks        int
+	Data         []DataRow
+	BorderSize   float64
+	FooterHeight float64
+	ImageHeight  float64
+	ImageWidth   float64
+	HeaderHeight float64
+	RowHeight    float64
+	PaddingSize  float64
+	NameWidth    float64
+	Rows         float64
+}
+
+func New(colorScheme, team string, season database.Season, weeks int, data []DataRow, variants ...string) H2H {
+	h2h := H2H{
+		ColorScheme:  colorScheme,
+		Team:         team,
+		Variants:     variants,
+		Season:       season,
+		Weeks:        weeks,
+		Data:         data,
+		BorderSize:   float64(2),
+		FooterHeight: float64(14),
+		ImageWidth:   float64(816),
+		HeaderHeight: float64(24),
+		RowHeight:    float64(18),
+		PaddingSize:  float64(3),
+		NameWidth:    float64(200),
+		Rows:         float64(len(data)),
+	}
+	// overview and head-to-head tables, and the 3 weekly tables below a row of week labels
+	tableRows := (h2h.Rows+1)*2 + 1 + (h2h.Rows+1)*3
+	h2h.ImageHeight = tableRows*h2h.RowHeight + h2h.HeaderHeight + h2h.PaddingSize*7
+	return h2h
+}
+
+func IsAvailable(colorScheme string, seasonID int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "h2h", seasonID, -1, team, variants...)
+}
+
+func Filename(seasonID int, team string, variants ...string) string {
+	return image.ImageFilename("h2h", seasonID, -1, team, variants...)
+}
+
+func (h *H2H) Filename() string {
+	return Filename(h.Season.SeasonID, h.Team, h.Variants...)
+}
+
+func (h *H2H) Draw() error {
+	h2hDraws.Inc()
+
+	// h2h title
+	h2hTitle := fmt.Sprintf("%s - Head to Head", h.Season.SeasonName)
+	if len(h.Season.SeasonName) > 52 {
+		h2hTitle = h.Season.SeasonName
+	}
+	h2hWeeksTitle := fmt.Sprintf("After %d week", h.Weeks)
+	if h.Weeks != 1 {
+		h2hWeeksTitle += "s" // plural
+	}
+
+	log.Infof("draw h2h for [%s] - [%s]", h2hTitle, h2hWeeksTitle)
+
+	// colorizer
+	if len(h.ColorScheme) == 0 {
+		h.ColorScheme = h.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(h.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(h.ImageWidth), int(h.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, h.ImageWidth, h.HeaderHeight)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(h.ImageWidth/1.5, 0, h.ImageWidth/3, h.HeaderHeight)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw h2h title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(h2hTitle, h.ImageWidth/3, h.HeaderHeight/2, 0.5, 0.5)
+	// draw weeks title
+	dc.DrawStringAnchored(h2hWeeksTitle, h.ImageWidth/2+h.ImageWidth/3, h.HeaderHeight/2, 0.5, 0.5)
+
+	xPos := h.PaddingSize
+	xLength := h.ImageWidth - h.PaddingSize*2
+	xColumnsStart := xPos + h.NameWidth
+
+	// draws a header row with a title in the name column and labels centered over the given number of columns
+	drawHeader := func(yPos float64, title string, labels []string) error {
+		dc.DrawRectangle(xPos, yPos, xLength, h.RowHeight)
+		color.TopNHeaderBG(dc)
+		dc.Fill()
+
+		color.TopNHeaderFG(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(title, xPos+h.PaddingSize*2, yPos+h.RowHeight/2, 0, 0.5)
+		columnWidth := (xLength - h.NameWidth) / float64(len(labels))
+		for l, label := range labels {
+			dc.DrawStringAnchored(label, xColumnsStart+float64(l)*columnWidth+columnWidth/2, yPos+h.RowHeight/2, 0.5, 0.5)
+		}
+
+		// draw outline
+		color.TopNHeaderOutline(dc)
+		dc.DrawRectangle(xPos, yPos, xLength, h.RowHeight)
+		dc.SetLineWidth(1)
+		dc.Stroke()
+		return nil
+	}
+	// draws a driver row with the driver color and name, and values centered in the given number of columns,
+	// highlighted values are drawn in the danger color
+	drawRow := func(yPos float64, d int, values []string, highlighted []bool) error {
+		dc.DrawRectangle(xPos, yPos, xLength, h.RowHeight)
+		if d%2 == 0 {
+			color.TopNCellDarkerBG(dc)
+		} else {
+			color.TopNCellLighterBG(dc)
+		}
+		dc.Fill()
+
+		rgb := palette[d%len(palette)]
+		dc.SetRGB255(rgb[0], rgb[1], rgb[2])
+		dc.DrawRectangle(xPos+h.PaddingSize*2, yPos+h.RowHeight/4, h.PaddingSize*2, h.RowHeight/2)
+		dc.Fill()
+
+		color.TopNCellDriver(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(h.Data[d].Driver, xPos+h.PaddingSize*6, yPos+h.RowHeight/2, 0, 0.5)
+
+		columnWidth := (xLength - h.NameWidth) / float64(len(values))
+		fontSize := float64(12)
+		if columnWidth < 60 {
+			fontSize = 10 // laptimes of many weeks
+		}
+		if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", fontSize); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		for v, value := range values {
+			color.TopNCellValue(dc)
+			if highlighted != nil && highlighted[v] {
+				color.TopNCellValueDanger(dc)
+			}
+			dc.DrawStringAnchored(value, xColumnsStart+float64(v)*columnWidth+columnWidth/2, yPos+h.RowHeight/2, 0.5, 0.5)
+		}
+
+		// draw outline
+		color.TopNCellOutline(dc)
+		dc.DrawRectangle(xPos, yPos, xLength, h.RowHeight)
+		dc.SetLineWidth(0.5)
+		dc.Stroke()
+		return nil
+	}
+
+	// overview of the whole season
+	yPos := h.HeaderHeight + h.PaddingSize
+	if err := drawHeader(yPos, "Driver", []string{"Races", "Wins", "Laps", "Incidents", "Inc./Lap", "iRating", "iRating +/-"}); err != nil {
+		return err
+	}
+	for d, data := range h.Data {
+		yPos += h.RowHeight
+		incidentsPerLap := "-"
+		if data.Laps > 0 {
+			incidentsPerLap = fmt.Sprintf("%.3f", float64(data.Incidents)/float64(data.Laps))
+		}
+		iRatingGain := fmt.Sprintf("%d", data.IRatingAfter-data.IRatingBefore)
+		if data.IRatingAfter-data.IRatingBefore > 0 {
+			iRatingGain = "+" + iRatingGain
+		}
+		values := []string{
+			fmt.Sprintf("%d", data.Races),
+			fmt.Sprintf("%d", data.Wins),
+			fmt.Sprintf("%d", data.Laps),
+			fmt.Sprintf("%d", data.Incidents),
+			incidentsPerLap,
+			fmt.Sprintf("%d", data.IRatingAfter),
+			iRatingGain,
+		}
+		if err := drawRow(yPos, d, values, nil); err != nil {
+			return err
+		}
+	}
+
+	// head-to-head in shared races, how often each driver finished ahead of the others
+	yPos += h.RowHeight + h.PaddingSize
+	opponents := make([]string, 0)
+	for _, data := range h.Data {
+		opponents = append(opponents, "vs. "+data.Driver)
+	}
+	if err := drawHeader(yPos, "Finished ahead in shared races", opponents); err != nil {
+		return err
+	}
+	for d, data := range h.Data {
+		yPos += h.RowHeight
+		values := make([]string, 0)
+		highlighted := make([]bool, 0)
+		for o := range h.Data {
+			if o == d || o >= len(data.Shared) || data.Shared[o] == 0 {
+				values = append(values, "-")
+				highlighted = append(highlighted, false)
+				continue
+			}
+			values = append(values, fmt.Sprintf("%d / %d", data.Ahead[o], data.Shared[o]))
+			highlighted = append(highlighted, data.Ahead[o]*2 > data.Shared[o]) // won the duel
+		}
+		if err := drawRow(yPos, d, values, highlighted); err != nil {
+			return err
+		}
+	}
+
+	// weekly tables
+	yPos += h.RowHeight + h.PaddingSize
+	weeks := make([]string, 0)
+	for week := 0; week < h.Weeks; week++ {
+		weeks = append(weeks, fmt.Sprintf("W%d", week+1))
+	}
+	if len(weeks) == 0 {
+		weeks = append(weeks, "-")
+	}
+	if err := drawHeader(yPos, "Week", weeks); err != nil {
+		return err
+	}
+	for _, table := range []struct {
+		Title  string
+		Values func(data DataRow, week int) (string, float64) // value and its score, the best score of each week gets highlighted
+	}{
+		{"Championship Points", func(data DataRow, week int) (string, float64) {
+			if week >= len(data.ChampPoints) || data.ChampPoints[week] < 0 {
+				return "-", -1
+			}
+			return fmt.Sprintf("%d", data.ChampPoints[week]), float64(data.ChampPoints[week])
+		}},
+		{"Best Race Lap", func(data DataRow, week int) (string, float64) {
+			if week >= len(data.BestLaps) || data.BestLaps[week] <= 0 {
+				return "-", -1
+			}
+			return util.ConvertLaptime(data.BestLaps[week]), -float64(data.BestLaps[week])
+		}},
+		{"iRating", func(data DataRow, week int) (string, float64) {
+			if week >= len(data.IRatings) || data.IRatings[week] <= 0 {
+				return "-", -1
+			}
+			return fmt.Sprintf("%d", data.IRatings[week]), float64(data.IRatings[week])
+		}},
+	} {
+		yPos += h.RowHeight + h.PaddingSize
+		if err := drawHeader(yPos, table.Title, []string{""}); err != nil {
+			return err
+		}
+		// find the best of each week
+		best := make([]int, len(weeks))
+		for week := range weeks {
+			best[week] = -1
+			bestScore := float64(-1)
+			for d, data := range h.Data {
+				if _, score := table.Values(data, week); score != -1 && (best[week] == -1 || score > bestScore) {
+					best[week] = d
+					bestScore = score
+				}
+			}
+		}
+		for d, data := range h.Data {
+			yPos += h.RowHeight
+			values := make([]string, 0)
+			highlighted := make([]bool, 0)
+			for week := range weeks {
+				value, _ := table.Values(data, week)
+				values = append(values, value)
+				highlighted = append(highlighted, len(h.Data) > 1 && best[week] == d)
+			}
+			if err := drawRow(yPos, d, values, highlighted); err != nil {
+				return err
+			}
+		}
+	}
+
+	// add border to image
+	bdc := gg.NewContext(int(h.ImageWidth+h.BorderSize*2), int(h.ImageHeight+h.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(h.BorderSize), int(h.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(h.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := time.Now().UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-h.FooterHeight/2, float64(bdc.Height())+h.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", h.FooterHeight/2, float64(bdc.Height())+h.FooterHeight/2, 0, 0.5)
+
+	if err := h.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(h.Filename()) // finally write to file
+}
@@ -0,0 +1,22 @@
+package h2h
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (h *H2H) MetadataFilename() string {
+	return image.MetadataFilename("h2h", h.Season.SeasonID, -1, h.Team, h.Variants...)
+}
+
+func (h *H2H) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(h.MetadataFilename())
+}
+
+func (h *H2H) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(h.ColorScheme, "h2h",
+		h.Season.SeasonID, -1,
+		h.Season.SeasonName, h.Season.Year, h.Season.Quarter,
+		"h2h", h.Team, h.Season.StartDate, h.Variants...,
+	)
+}
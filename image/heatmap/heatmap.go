@@ -2,16 +2,19 @@ package heatmap
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRvisualizer/image"
 	scheme "github.com/JamesClonk/iRvisualizer/image/color"
 	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/util"
 	"github.com/fogleman/gg"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"github.com/robfig/cron"
 )
 
 var (
@@ -35,6 +38,51 @@ type Heatmap struct {
 	TimeslotHeight float64
 	DayWidth       float64
 	Days           int
+	LegendHeight   float64
+	Previous       []database.RaceWeekResult // results of the previous season, for diff heatmaps
+	Options
+}
+
+// Options holds all heatmap options that result in a different image
+type Options struct {
+	Location   *time.Location   // timezone to layout days and timeslots in
+	Metric     string           // metric to color timeslots by
+	Legend     bool             // draw a legend with the color scale below the heatmap
+	Seasons    int              // number of seasons averaged together, for series heatmaps
+	Diff       bool             // color by difference to the previous season instead, for series heatmaps
+	Layout     string           // layout of the timeslot cells
+	Gradient   string           // predefined gradient or list of hex colors, instead of the color scheme mapping
+	Scale      string           // scaling of metric values onto the colors
+	AutoScale  bool             // figure out min/max of the color scale from percentiles of the data
+	Enrichment image.Enrichment // track and car details in the header
+}
+
+const (
+	LayoutCells  = "cells"  // one value per timeslot, all splits collapsed together
+	LayoutSplits = "splits" // mini bars per split, height by field size and colored by SOF
+)
+
+const (
+	MetricSOF          = "sof"
+	MetricDrivers      = "drivers"
+	MetricSplits       = "splits"
+	MetricOfficialRate = "official_rate"
+)
+
+var metricTitles = map[string]string{
+	MetricSOF:          "SOF",
+	MetricDrivers:      "Drivers",
+	MetricSplits:       "Splits",
+	MetricOfficialRate: "Official Sessions",
+}
+
+func IsMetric(metric string) bool {
+	_, ok := metricTitles[metric]
+	return ok
+}
+
+func IsLayout(layout string) bool {
+	return layout == LayoutCells || layout == LayoutSplits
 }
 
 func New(colorScheme string, season database.Season, week database.RaceWeek, track database.Track, results []database.RaceWeekResult) Heatmap {
@@ -52,19 +100,64 @@ func New(colorScheme string, season database.Season, week database.RaceWeek, tra
 		TimeslotHeight: float64(50),
 		DayWidth:       float64(128),
 		Days:           7, // pretty sure that's never gonna change..
+		LegendHeight:   float64(40),
+		Options:        DefaultOptions(),
 	}
 }
 
-func IsAvailable(colorScheme string, seasonID, week int) bool {
-	return image.IsAvailable(colorScheme, "heatmap", seasonID, week, "")
+func DefaultOptions() Options {
+	return Options{
+		Location: time.UTC,
+		Metric:   MetricSOF,
+		Layout:   LayoutCells,
+		Scale:    image.ScaleLinear,
+	}
 }
 
-func Filename(seasonID, week int) string {
-	return image.ImageFilename("heatmap", seasonID, week, "")
+// Variants returns the image file variants for the heatmap options, default options have none
+func (o Options) Variants() []string {
+	variants := make([]string, 0)
+	if o.Seasons > 0 {
+		variants = append(variants, fmt.Sprintf("seasons_%d", o.Seasons))
+	}
+	if o.Diff {
+		variants = append(variants, "diff")
+	}
+	if len(o.Metric) > 0 && o.Metric != MetricSOF {
+		variants = append(variants, "metric_"+o.Metric)
+	}
+	if o.Layout == LayoutSplits {
+		variants = append(variants, "layout_splits")
+	}
+	if len(o.Gradient) > 0 {
+		variants = append(variants, "gradient_"+strings.NewReplacer("#", "", ",", "-").Replace(o.Gradient))
+	}
+	if len(o.Scale) > 0 && o.Scale != image.ScaleLinear {
+		variants = append(variants, "scale_"+o.Scale)
+	}
+	if o.AutoScale {
+		variants = append(variants, "auto")
+	}
+	if o.Legend {
+		variants = append(variants, "legend")
+	}
+	variants = append(variants, o.Enrichment.Variants()...)
+	if o.Location != nil && o.Location.String() != time.UTC.String() {
+		variants = append(variants, "tz_"+o.Location.String())
+	}
+	return variants
+}
+
+func IsAvailable(colorScheme string, seasonID, week int, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "heatmap", seasonID, week, "", variants...)
+}
+
+func Filename(seasonID, week int, variants ...string) string {
+	return image.ImageFilename("heatmap", seasonID, week, "", variants...)
 }
 
 func (h *Heatmap) Filename() string {
-	return Filename(h.Season.SeasonID, h.Week.RaceWeek+1)
+	return Filename(h.Season.SeasonID, h.Week.RaceWeek+1, h.Variants()...)
 }
 
 func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
@@ -72,31 +165,87 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 
 	// heatmap titles, season + track
 	heatmapTitle := fmt.Sprintf("%s - Week %d", h.Season.SeasonName, h.Week.RaceWeek+1)
-	heatmap2ndTitle := h.Track.Name
+	heatmap2ndTitle := h.Enrichment.TrackTitle(h.Track)
 	if h.Week.RaceWeek == -1 { // seasonal avg. map
 		heatmapTitle = h.Season.SeasonName
 		heatmap2ndTitle = "Seasonal Average"
+		if h.Seasons > 1 {
+			heatmap2ndTitle = fmt.Sprintf("Average of last %d Seasons", h.Seasons)
+		}
+		if h.Diff {
+			heatmap2ndTitle = "Change to previous Season"
+		}
+	}
+	if h.Metric != MetricSOF {
+		heatmap2ndTitle = fmt.Sprintf("%s - %s", heatmap2ndTitle, metricTitles[h.Metric])
+	}
+	if h.Layout == LayoutSplits {
+		heatmap2ndTitle = fmt.Sprintf("%s - Splits", heatmap2ndTitle)
 	}
-	// if len(track.Config) > 0 {
-	// 	heatmap2ndTitle = fmt.Sprintf("%s - %s", h.Track.Name, h.Track.Config)
-	// }
 
 	log.Infof("draw heatmap for [%s] - [%s]", heatmapTitle, heatmap2ndTitle)
 
+	if h.Location == nil {
+		h.Location = time.UTC
+	}
+	if !IsMetric(h.Metric) {
+		h.Metric = MetricSOF
+	}
+	weekStart := database.WeekStart(h.Season.StartDate.UTC().AddDate(0, 0, (h.Week.RaceWeek)*h.Days))
+	weekEnd := weekStart.AddDate(0, 0, h.Days)
+
 	// figure out timeslots schedule
-	p := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	schedule, err := p.Parse(h.Season.Timeslots)
+	schedule, err := util.SessionTimes(h.Season.Timeslots, weekStart, weekEnd)
 	if err != nil {
-		return fmt.Errorf("could not parse timeslot [%s] to crontab format: %v", h.Season.Timeslots, err)
+		return err
 	}
-	// start -1 minute to previous day, to make sure schedule.Next will catch a midnight start (00:00)
-	weekStart := database.WeekStart(h.Season.StartDate.UTC().AddDate(0, 0, (h.Week.RaceWeek)*h.Days))
-	start := weekStart.Add(-1 * time.Minute)
-	timeslots := make([]time.Time, 0)
-	next := schedule.Next(start)                             // get first timeslot
-	for next.Before(schedule.Next(start.AddDate(0, 0, 1))) { // collect all timeslots of 1 day
-		timeslots = append(timeslots, next)
-		next = schedule.Next(next)
+
+	// collect all sessions of the whole week, and all distinct local starting times of them
+	sessions := make(map[int64]bool)
+	localStarts := make(map[int]bool) // minutes since local midnight
+	for _, next := range schedule {
+		sessions[next.Unix()] = true
+		local := next.In(h.Location)
+		localStarts[local.Hour()*60+local.Minute()] = true
+	}
+	timeslots := make([]int, 0)
+	for minutes := range localStarts {
+		timeslots = append(timeslots, minutes)
+	}
+	sort.Ints(timeslots)
+	if len(timeslots) == 0 {
+		return fmt.Errorf("could not find any timeslots for [%s]", h.Season.Timeslots)
+	}
+
+	// days are laid out in local time, starting with the local date the raceweek starts on
+	y, m, d := weekStart.In(h.Location).Date()
+	dayStart := time.Date(y, m, d, 0, 0, 0, 0, h.Location)
+	// sessionTime returns the session time of a day/timeslot cell, wrapped to be within the raceweek.
+	// sessions from before the raceweek start in local time belong to the end of the raceweek instead.
+	sessionTime := func(day, slot int) time.Time {
+		y, m, d := dayStart.AddDate(0, 0, day).Date()
+		t := time.Date(y, m, d, timeslots[slot]/60, timeslots[slot]%60, 0, 0, h.Location)
+		if t.Before(weekStart) {
+			t = t.AddDate(0, 0, h.Days)
+		}
+		if !t.Before(weekEnd) {
+			t = t.AddDate(0, 0, -h.Days)
+		}
+		return t
+	}
+
+	// timezone label, like "EDT / GMT-4"
+	zoneName, zoneOffset := weekStart.In(h.Location).Zone()
+	zoneLabel := fmt.Sprintf("GMT%+d", zoneOffset/3600)
+	if zoneOffset%3600 != 0 {
+		minutes := (zoneOffset % 3600) / 60
+		if minutes < 0 {
+			minutes = -minutes
+		}
+		zoneLabel = fmt.Sprintf("%s:%02d", zoneLabel, minutes)
+	}
+	if !strings.HasPrefix(zoneName, "+") && !strings.HasPrefix(zoneName, "-") {
+		zoneLabel = fmt.Sprintf("%s / %s", zoneName, zoneLabel)
 	}
 
 	// adjust font size if timeslots are shorter than every 2 hours
@@ -109,27 +258,109 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 		fontMultiplierField = 0.95
 	}
 
-	// figure out dynamic SOF
-	if minSOF == 0 {
-		minSOF = 1000
+	// collect results of all timeslots
+	results := make([][]image.Timeslot, h.Days)
+	previous := make([][]image.Timeslot, h.Days)
+	splits := make([][][]image.Split, h.Days)
+	maxSplitSize := 1
+	for day := 0; day < h.Days; day++ {
+		results[day] = make([]image.Timeslot, len(timeslots))
+		previous[day] = make([]image.Timeslot, len(timeslots))
+		splits[day] = make([][]image.Split, len(timeslots))
+		for slot := 0; slot < len(timeslots); slot++ {
+			results[day][slot] = image.GetTimeslot(sessionTime(day, slot), h.Results)
+			previous[day][slot] = image.GetTimeslot(sessionTime(day, slot), h.Previous)
+			if h.Layout == LayoutSplits {
+				splits[day][slot] = image.GetSplits(sessionTime(day, slot), h.Results)
+				for _, split := range splits[day][slot] {
+					if split.SizeOfField > maxSplitSize {
+						maxSplitSize = split.SizeOfField
+					}
+				}
+			}
+		}
+	}
+
+	// figure out color scale of the metric
+	var minValue, maxValue float64
+	switch {
+	case h.Diff:
+		// symmetric scale around 0, for gains and losses
+		maxValue = 1
+		for day := range results {
+			for slot := range results[day] {
+				if diff := math.Abs(h.value(results[day][slot]) - h.value(previous[day][slot])); diff > maxValue {
+					maxValue = diff
+				}
+			}
+		}
+		minValue = -maxValue
+	case h.Metric == MetricSOF:
+		// figure out dynamic SOF
+		if minSOF == 0 {
+			minSOF = 1000
+		}
+		if maxSOF == 0 {
+			maxSOF = minSOF * 2
+			for _, result := range h.Results {
+				if result.StrengthOfField > maxSOF {
+					maxSOF = result.StrengthOfField
+				}
+			}
+		}
+		minValue, maxValue = float64(minSOF), float64(maxSOF)
+	case h.Metric == MetricOfficialRate:
+		minValue, maxValue = 0, 100
+	default:
+		maxValue = 1
+		for day := range results {
+			for _, result := range results[day] {
+				if value := h.value(result); value > maxValue {
+					maxValue = value
+				}
+			}
+		}
 	}
-	if maxSOF == 0 {
-		maxSOF = minSOF * 2
-		for _, result := range h.Results {
-			if result.StrengthOfField > maxSOF {
-				maxSOF = result.StrengthOfField
+
+	// collect all metric values, for automatic and quantile scales
+	values := make([]float64, 0)
+	for day := range results {
+		for _, result := range results[day] {
+			if value := h.value(result); value > 0 {
+				values = append(values, value)
 			}
 		}
 	}
+	if h.AutoScale && !h.Diff && len(values) > 1 {
+		minValue, maxValue = util.Quantile(values, 0.05), util.Quantile(values, 0.95)
+		if maxValue <= minValue {
+			maxValue = minValue + 1
+		}
+	}
 
 	// colorizer
 	if len(h.ColorScheme) == 0 {
 		h.ColorScheme = h.Season.SeriesColorScheme // get series default if needed
 	}
 	color := scheme.Get(h.ColorScheme)
+	colors := colorScale{Scale: image.NewScale(h.Scale, minValue, maxValue, values), color: color, diff: h.Diff}
+	if h.Diff {
+		colors.Scale = image.NewScale(image.ScaleLinear, minValue, maxValue, nil)
+	}
+	if len(h.Gradient) > 0 {
+		gradient, err := scheme.GetGradient(h.Gradient)
+		if err != nil {
+			return err
+		}
+		colors.gradient = gradient
+	}
 
 	// create canvas
-	dc := gg.NewContext(int(h.ImageWidth), int(h.ImageHeight))
+	canvasHeight := h.ImageHeight
+	if h.Legend {
+		canvasHeight += h.LegendHeight
+	}
+	dc := gg.NewContext(int(h.ImageWidth), int(canvasHeight))
 
 	// background
 	color.Background(dc)
@@ -149,22 +380,37 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 	}
 	color.HeaderFG(dc)
 	dc.DrawStringAnchored(heatmapTitle, h.DayWidth/7, h.HeaderHeight/2, 0, 0.5)
-	// draw track config
+	// draw track map and car logos, if enabled
+	var assetsWidth float64
+	if h.Week.RaceWeek != -1 {
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		assetsWidth = h.Enrichment.DrawAssets(dc, h.Track, h.ImageWidth-h.DayWidth/7, 0, h.HeaderHeight)
+		if assetsWidth > 0 {
+			assetsWidth += h.DayWidth / 7
+		}
+	}
+	// draw track title
 	if err := dc.LoadFontFace("public/fonts/Roboto-Italic.ttf", 19); err != nil {
 		return fmt.Errorf("could not load font: %v", err)
 	}
 	color.HeaderFG(dc)
-	dc.DrawStringAnchored(heatmap2ndTitle, h.ImageWidth-h.DayWidth/7, h.HeaderHeight/2, 1, 0.5)
+	dc.DrawStringAnchored(heatmap2ndTitle, h.ImageWidth-h.DayWidth/7-assetsWidth, h.HeaderHeight/2, 1, 0.5)
 
 	// timeslots
 	dc.DrawRectangle(0, h.HeaderHeight, h.DayWidth, h.TimeslotHeight)
 	color.HeatmapHeaderDarkerBG(dc)
 	dc.Fill()
-	if err := dc.LoadFontFace("public/fonts/roboto-mono_thin.ttf", 14); err != nil {
+	zoneFontSize := 14.0
+	if len(zoneLabel) > 12 {
+		zoneFontSize = 12
+	}
+	if err := dc.LoadFontFace("public/fonts/roboto-mono_thin.ttf", zoneFontSize); err != nil {
 		return fmt.Errorf("could not load font: %v", err)
 	}
 	color.HeatmapHeaderFG(dc)
-	dc.DrawStringAnchored("UTC / GMT+0", h.DayWidth/2, h.HeaderHeight+h.TimeslotHeight/2, 0.5, 0.5)
+	dc.DrawStringAnchored(zoneLabel, h.DayWidth/2, h.HeaderHeight+h.TimeslotHeight/2, 0.5, 0.5)
 	if err := dc.LoadFontFace("public/fonts/roboto-mono_medium.ttf", 16*fontMultiplierSlots); err != nil {
 		return fmt.Errorf("could not load font: %v", err)
 	}
@@ -180,7 +426,7 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 		// draw timeslot starting time
 		color.HeatmapHeaderFG(dc)
 		dc.DrawStringAnchored(
-			timeslots[slot].Format("15:04"),
+			fmt.Sprintf("%02d:%02d", timeslots[slot]/60, timeslots[slot]%60),
 			(float64(slot)*(timeslotWidth+1))+(h.DayWidth+1)+(timeslotWidth/2),
 			h.HeaderHeight+h.TimeslotHeight/2,
 			0.5, 0.5)
@@ -202,7 +448,7 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 		// draw weekday name
 		color.HeatmapHeaderFG(dc)
 		dc.DrawStringAnchored(
-			weekStart.AddDate(0, 0, day).Weekday().String(),
+			dayStart.AddDate(0, 0, day).Weekday().String(),
 			h.DayWidth/2,
 			(float64(day)*(dayHeight+1))+(h.HeaderHeight+h.TimeslotHeight+1)+dayHeight/2,
 			0.5, 0.5)
@@ -220,27 +466,54 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 			color.HeatmapTimeslotBG(dc)
 			dc.Fill()
 
+			// is there a session at all in this cell? could be missing due to DST changes or daily differences
+			timeslot := sessionTime(day, slot)
+			if !sessions[timeslot.Unix()] {
+				continue
+			}
+
 			// draw event values
-			timeslot := weekStart.AddDate(0, 0, day).Add(time.Hour * time.Duration(timeslots[slot].Hour())).Add(time.Minute * time.Duration(timeslots[slot].Minute()))
-			result := image.GetResult(timeslot, h.Results)
+			result := results[day][slot]
 
 			// only draw empty slots if enabled
 			if result.Official || drawEmptySlots {
 				// only draw event if a session actually happened already
 				if timeslot.Before(time.Now().Add(time.Hour * -2)) {
-					sof := 0
-					if result.Official {
-						sof = result.StrengthOfField
-						if result.StrengthOfField >= minSOF {
-							// draw background color
+					if h.Layout == LayoutSplits {
+						if err := h.drawSplits(dc, color, colors, splits[day][slot], maxSplitSize, slotX, slotY, eventWidth, eventHeight); err != nil {
+							return err
+						}
+						continue
+					}
+
+					value := h.value(result)
+					text := h.format(value, fontMultiplierSOF < 1.0)
+					if h.Diff {
+						// only compare timeslots that had sessions in both seasons
+						if result.RaceWeeks > 0 && previous[day][slot].RaceWeeks > 0 {
+							diff := value - h.value(previous[day][slot])
 							dc.DrawRectangle(slotX, slotY, eventWidth, eventHeight)
-							color.HeatmapTimeslotMapping(dc, minSOF, maxSOF, sof) // sof color
+							colors.set(dc, diff) // gain or loss color
 							dc.Fill()
+							text = h.formatDiff(diff)
+						} else {
+							text = "0"
 						}
+					} else if value > 0 && (value >= minValue || h.Metric != MetricSOF) {
+						// draw background color
+						dc.DrawRectangle(slotX, slotY, eventWidth, eventHeight)
+						colors.set(dc, value) // metric color
+						dc.Fill()
 					}
-					sofText := fmt.Sprintf("%d", sof)
-					if fontMultiplierSOF < 1.0 {
-						sofText = fmt.Sprintf("%.1fk", float64(sof)/1000)
+
+					// secondary value, the field size. or the SOF if the metric already is the field size
+					secondary := fmt.Sprintf("%d", result.SizeOfField)
+					if h.Metric == MetricDrivers {
+						sof := 0
+						if result.Official {
+							sof = result.StrengthOfField
+						}
+						secondary = fmt.Sprintf("%d", sof)
 					}
 
 					color.HeatmapTimeslotFG(dc)
@@ -251,19 +524,26 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 					if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 15*fontMultiplierSOF); err != nil {
 						return fmt.Errorf("could not load font: %v", err)
 					}
-					textWithBorder(dc, color, sofText, slotX+eventWidth/2, slotY+eventHeight/3-1)
+					textWithBorder(dc, color, text, slotX+eventWidth/2, slotY+eventHeight/3-1)
 
 					if err := dc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 13*fontMultiplierField); err != nil {
 						return fmt.Errorf("could not load font: %v", err)
 					}
-					textWithBorder(dc, color, fmt.Sprintf("%d", result.SizeOfField), slotX+eventWidth/2, slotY+eventHeight/1.5+1)
+					textWithBorder(dc, color, secondary, slotX+eventWidth/2, slotY+eventHeight/1.5+1)
 				}
 			}
 		}
 	}
 
+	// legend
+	if h.Legend {
+		if err := h.drawLegend(dc, color, colors); err != nil {
+			return err
+		}
+	}
+
 	// add border to image
-	bdc := gg.NewContext(int(h.ImageWidth+h.BorderSize*2), int(h.ImageHeight+h.BorderSize*2))
+	bdc := gg.NewContext(int(h.ImageWidth+h.BorderSize*2), int(canvasHeight+h.BorderSize*2))
 	color.Border(bdc)
 	bdc.Clear()
 	bdc.DrawImage(dc.Image(), int(h.BorderSize), int(h.BorderSize))
@@ -293,6 +573,201 @@ func (h *Heatmap) Draw(minSOF, maxSOF int, drawEmptySlots bool) error {
 	return fdc.SavePNG(h.Filename()) // finally write to file
 }
 
+// drawLegend draws the color scale of the heatmap metric below the heatmap
+func (h *Heatmap) drawLegend(dc *gg.Context, color scheme.Colorizer, colors colorScale) error {
+	yPos := h.ImageHeight + 1
+	height := h.LegendHeight - 1
+
+	// metric title
+	dc.DrawRectangle(0, yPos, h.DayWidth, height)
+	color.HeatmapHeaderDarkerBG(dc)
+	dc.Fill()
+	if err := dc.LoadFontFace("public/fonts/RobotoCondensed-Regular.ttf", 16); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeatmapHeaderFG(dc)
+	dc.DrawStringAnchored(metricTitles[h.Metric], h.DayWidth/2, yPos+height/2, 0.5, 0.5)
+
+	// color scale background
+	dc.DrawRectangle(h.DayWidth+1, yPos, h.ImageWidth-h.DayWidth-1, height)
+	color.HeatmapHeaderLighterBG(dc)
+	dc.Fill()
+
+	// gradient bar, drawn with the same color mapping as the timeslots
+	xStart := h.DayWidth + h.DayWidth/4
+	xLength := h.ImageWidth - xStart - h.DayWidth/4
+	barHeight := height / 3
+	dc.DrawRectangle(xStart, yPos+4, xLength, barHeight)
+	color.HeatmapTimeslotBG(dc)
+	dc.Fill()
+	for x := 0.0; x < xLength; x++ {
+		dc.DrawRectangle(xStart+x, yPos+4, 1, barHeight)
+		colors.set(dc, colors.Value(x/xLength))
+		dc.Fill()
+	}
+	color.HeatmapTimeslotFG(dc)
+	dc.SetLineWidth(0.5)
+	dc.DrawRectangle(xStart, yPos+4, xLength, barHeight)
+	dc.Stroke()
+
+	// tick labels
+	if err := dc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 11); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	ticks := 6
+	for tick := 0; tick <= ticks; tick++ {
+		value := colors.Value(float64(tick) / float64(ticks))
+		xPos := xStart + xLength*float64(tick)/float64(ticks)
+		color.HeatmapTimeslotFG(dc)
+		dc.DrawLine(xPos, yPos+4+barHeight, xPos, yPos+4+barHeight+3)
+		dc.Stroke()
+
+		label := h.format(math.Round(value), false)
+		if h.Metric == MetricSplits {
+			label = h.format(math.Round(value*10)/10, false)
+		}
+		if h.Diff {
+			label = h.formatDiff(value)
+		} else if tick == ticks && h.Metric != MetricOfficialRate {
+			label += "+" // higher values all get the same color
+		}
+		color.HeatmapHeaderFG(dc)
+		dc.DrawStringAnchored(label, xPos, yPos+height-2, 0.5, 0)
+	}
+	return nil
+}
+
+// drawSplits draws a mini bar per split into the timeslot cell, with the height by field size and colored by SOF
+func (h *Heatmap) drawSplits(dc *gg.Context, color scheme.Colorizer, colors colorScale, splits []image.Split, maxSize int, x, y, width, height float64) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	// top split SOF as the cell title
+	if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 11); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	textWithBorder(dc, color, fmt.Sprintf("%d", splits[0].StrengthOfField), x+width/2, y+8)
+
+	// bars
+	padding := 2.0
+	barWidth := (width - padding*2) / float64(len(splits))
+	barAreaY := y + 14
+	if barWidth >= 12 {
+		barAreaY += 9 // room for field size labels
+	}
+	barAreaHeight := y + height - padding - barAreaY
+	if err := dc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 8); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	for s, split := range splits {
+		barHeight := barAreaHeight * float64(split.SizeOfField) / float64(maxSize)
+		barX := x + padding + float64(s)*barWidth
+		barY := barAreaY + barAreaHeight - barHeight
+
+		dc.DrawRectangle(barX, barY, barWidth-1, barHeight)
+		if split.Official {
+			colors.set(dc, float64(split.StrengthOfField)) // sof color
+		} else {
+			color.HeatmapTimeslotZero(dc)
+		}
+		dc.Fill()
+		color.HeatmapTimeslotFG(dc)
+		dc.SetLineWidth(0.5)
+		dc.DrawRectangle(barX, barY, barWidth-1, barHeight)
+		dc.Stroke()
+
+		// field size above the bar, if there's enough space
+		if barWidth >= 12 {
+			dc.DrawStringAnchored(fmt.Sprintf("%d", split.SizeOfField), barX+(barWidth-1)/2, barY-1, 0.5, 0)
+		}
+	}
+	return nil
+}
+
+// value returns the value of the heatmap metric for a timeslot
+func (h *Heatmap) value(result image.Timeslot) float64 {
+	switch h.Metric {
+	case MetricDrivers:
+		return float64(result.SizeOfField)
+	case MetricSplits:
+		return result.Splits
+	case MetricOfficialRate:
+		return float64(result.OfficialRate)
+	default:
+		if !result.Official {
+			return 0
+		}
+		return float64(result.StrengthOfField)
+	}
+}
+
+// format returns the metric value as text, short if there's not much space in the timeslot
+func (h *Heatmap) format(value float64, short bool) string {
+	switch h.Metric {
+	case MetricSplits:
+		if value != math.Trunc(value) {
+			return fmt.Sprintf("%.1f", value)
+		}
+		return fmt.Sprintf("%.0f", value)
+	case MetricOfficialRate:
+		if value == 0 {
+			return "0"
+		}
+		return fmt.Sprintf("%.0f%%", value)
+	case MetricSOF:
+		if short {
+			return fmt.Sprintf("%.1fk", value/1000)
+		}
+	}
+	return fmt.Sprintf("%.0f", value)
+}
+
+// formatDiff returns the signed difference of the metric value as text
+func (h *Heatmap) formatDiff(diff float64) string {
+	if h.Metric == MetricSplits {
+		diff = math.Round(diff*10) / 10
+	} else {
+		diff = math.Round(diff)
+	}
+	if diff > 0 {
+		return "+" + h.format(diff, false)
+	}
+	if diff < 0 {
+		return "-" + h.format(-diff, false)
+	}
+	return "0"
+}
+
+// colorScale maps metric values to colors, either with the colorizer's own mapping or a custom gradient
+type colorScale struct {
+	image.Scale
+	color    scheme.Colorizer
+	gradient scheme.Gradient
+	diff     bool
+}
+
+func (c colorScale) set(dc *gg.Context, value float64) {
+	switch {
+	case c.diff:
+		diffMapping(dc, c.Max, value)
+	case len(c.gradient) > 0:
+		c.gradient.Set(dc, c.Position(value))
+	default:
+		c.color.HeatmapTimeslotMapping(dc, 0, 10000, int(c.Position(value)*10000))
+	}
+}
+
+// diffMapping colors gains in green and losses in red, the bigger the change the stronger the color
+func diffMapping(dc *gg.Context, maxValue, diff float64) {
+	alpha := image.MapValueIntoRange(10, 225, 0, int(maxValue*100), int(math.Abs(diff)*100))
+	if diff >= 0 {
+		dc.SetRGBA255(46, 160, 67, alpha)
+		return
+	}
+	dc.SetRGBA255(214, 39, 40, alpha)
+}
+
 func textWithBorder(dc *gg.Context, color scheme.Colorizer, text string, X, Y float64) {
 	if text != "0" {
 		color.Border(dc)
@@ -5,7 +5,7 @@ import (
 )
 
 func (h *Heatmap) MetadataFilename() string {
-	return image.MetadataFilename("heatmap", h.Season.SeasonID, h.Week.RaceWeek+1, "")
+	return image.MetadataFilename("heatmap", h.Season.SeasonID, h.Week.RaceWeek+1, "", h.Variants()...)
 }
 
 func (h *Heatmap) ReadMetadata() (meta image.Metadata) {
@@ -17,6 +17,6 @@ func (h *Heatmap) WriteMetadata() error {
 	return image.WriteMetadata(h.ColorScheme, "heatmap",
 		h.Season.SeasonID, h.Week.RaceWeek+1,
 		h.Season.SeasonName, h.Season.Year, h.Season.Quarter,
-		h.Track.Name, "", h.Season.StartDate,
+		h.Track.Name, "", h.Season.StartDate, h.Variants()...,
 	)
 }
@@ -25,15 +25,18 @@ type DataSet struct {
 	Driver   string
 	Laptime  database.Laptime
 	Marked   bool
+	Section  bool // section title row, with the title as Driver
 }
 
 type Laptime struct {
 	ColorScheme         string
 	Team                string
 	Name                string
+	Variants            []string
 	Season              database.Season
 	Week                database.RaceWeek
 	Track               database.Track
+	Enrichment          image.Enrichment // track and car details in the header
 	Data                []DataSet
 	BorderSize          float64
 	FooterHeight        float64
@@ -50,11 +53,12 @@ type Laptime struct {
 	DriverColumnWidth   float64
 }
 
-func New(colorScheme, team string, season database.Season, week database.RaceWeek, track database.Track, data []DataSet) Laptime {
+func New(colorScheme, team string, season database.Season, week database.RaceWeek, track database.Track, data []DataSet, variants ...string) Laptime {
 	lap := Laptime{
 		ColorScheme:         colorScheme,
 		Team:                team,
 		Name:                "laptimes",
+		Variants:            variants,
 		Season:              season,
 		Week:                week,
 		Track:               track,
@@ -76,16 +80,16 @@ func New(colorScheme, team string, season database.Season, week database.RaceWee
 	return lap
 }
 
-func IsAvailable(colorScheme string, seasonID, week int, team string) bool {
-	return image.IsAvailable(colorScheme, "laptimes", seasonID, week, team)
+func IsAvailable(colorScheme string, seasonID, week int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "laptimes", seasonID, week, team, variants...)
 }
 
-func Filename(seasonID, week int, team string) string {
-	return image.ImageFilename("laptimes", seasonID, week, team)
+func Filename(seasonID, week int, team string, variants ...string) string {
+	return image.ImageFilename("laptimes", seasonID, week, team, variants...)
 }
 
 func (l *Laptime) Filename() string {
-	return Filename(l.Season.SeasonID, l.Week.RaceWeek+1, l.Team)
+	return Filename(l.Season.SeasonID, l.Week.RaceWeek+1, l.Team, l.Variants...)
 }
 
 func (l *Laptime) Draw() error {
@@ -97,7 +101,7 @@ func (l *Laptime) Draw() error {
 		lapTitle = l.Season.SeasonName
 	}
 	lapWeekTitle := fmt.Sprintf("Week %d", l.Week.RaceWeek+1)
-	lapTrackTitle := l.Track.Name
+	lapTrackTitle := l.Enrichment.TrackTitle(l.Track)
 
 	log.Infof("draw laptimes for [%s] - [%s]", lapTitle, lapTrackTitle)
 
@@ -134,12 +138,17 @@ func (l *Laptime) Draw() error {
 	}
 	color.HeaderFG(dc)
 	dc.DrawStringAnchored(lapWeekTitle, l.ImageWidth/4, l.HeaderHeight/4*3, 0.5, 0.5)
+	// draw track map and car logos, if enabled
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	assetsWidth := l.Enrichment.DrawAssets(dc, l.Track, l.ImageWidth-l.PaddingSize, l.HeaderHeight/2, l.HeaderHeight/2)
 	// draw track title
 	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
 		return fmt.Errorf("could not load font: %v", err)
 	}
 	color.HeaderFG(dc)
-	dc.DrawStringAnchored(lapTrackTitle, l.ImageWidth/3*2, l.HeaderHeight/4*3, 0.5, 0.5)
+	dc.DrawStringAnchored(lapTrackTitle, l.ImageWidth/3*2-assetsWidth/2, l.HeaderHeight/4*3, 0.5, 0.5)
 
 	// adjust to header height
 	yPosColumnHeaderStart := l.HeaderHeight + l.PaddingSize
@@ -239,6 +248,20 @@ func (l *Laptime) Draw() error {
 		yPos := yPosRowStart + float64(row)*l.DriverHeight
 		xLength := l.ImageWidth - l.PaddingSize*2
 
+		// section title?
+		if entry.Section {
+			dc.DrawRectangle(xPos, yPos, xLength, l.DriverHeight)
+			color.HeaderRightBG(dc)
+			dc.Fill()
+
+			color.HeaderFG(dc)
+			if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 12); err != nil {
+				return fmt.Errorf("could not load font: %v", err)
+			}
+			dc.DrawStringAnchored(entry.Driver, xPos+xLength/2, yPos+l.DriverHeight/2, 0.5, 0.5)
+			continue
+		}
+
 		// zebra pattern
 		dc.DrawRectangle(xPos, yPos, xLength, l.DriverHeight)
 		if row%2 == 0 {
@@ -22,8 +22,8 @@ type Metadata struct {
 	LastUpdated   time.Time `json:"LastUpdated"`
 }
 
-func MetadataFilename(image string, seasonID, week int, team string) string {
-	return fmt.Sprintf("%s.json", ImageFilename(image, seasonID, week, team))
+func MetadataFilename(image string, seasonID, week int, team string, variants ...string) string {
+	return fmt.Sprintf("%s.json", ImageFilename(image, seasonID, week, team, variants...))
 }
 
 func GetMetadata(filename string) (meta Metadata) {
@@ -42,12 +42,12 @@ func GetMetadata(filename string) (meta Metadata) {
 	return meta
 }
 
-func WriteMetadata(colorScheme, image string, seasonID, week int, season string, year, quarter int, track, team string, startDate time.Time) error {
-	filename := MetadataFilename(image, seasonID, week, team)
+func WriteMetadata(colorScheme, image string, seasonID, week int, season string, year, quarter int, track, team string, startDate time.Time, variants ...string) error {
+	filename := MetadataFilename(image, seasonID, week, team, variants...)
 	log.Debugf("write metadata to [%s]", filename)
 
 	meta := Metadata{
-		ImageFilename: ImageFilename(image, seasonID, week, team),
+		ImageFilename: ImageFilename(image, seasonID, week, team, variants...),
 		Season:        season,
 		Year:          year,
 		Quarter:       quarter,
@@ -0,0 +1,22 @@
+package positions
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (p *Positions) MetadataFilename() string {
+	return image.MetadataFilename("positions", p.Season.SeasonID, -1, p.Team, p.Variants...)
+}
+
+func (p *Positions) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(p.MetadataFilename())
+}
+
+func (p *Positions) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(p.ColorScheme, "positions",
+		p.Season.SeasonID, -1,
+		p.Season.SeasonName, p.Season.Year, p.Season.Quarter,
+		"positions", p.Team, p.Season.StartDate, p.Variants...,
+	)
+}
@@ -0,0 +1,305 @@
+package positions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	positionsDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_positions_drawn_total",
+		Help: "Total position charts drawn by iRvisualizer.",
+	})
+)
+
+// line colors for the drivers, assigned in order of their final championship position
+var palette = [][3]int{
+	{31, 119, 180},
+	{255, 127, 14},
+	{44, 160, 44},
+	{214, 39, 40},
+	{148, 103, 189},
+	{140, 86, 75},
+	{227, 119, 194},
+	{127, 127, 127},
+	{188, 189, 34},
+	{23, 190, 207},
+	{0, 63, 92},
+	{188, 80, 144},
+	{255, 166, 0},
+	{102, 81, 145},
+	{0, 128, 96},
+}
+
+type DataRow struct {
+	Driver    string
+	Positions []int // championship position after each week, 0 if not yet classified
+	Marked    bool
+}
+
+type Positions struct {
+	ColorScheme  string
+	Team         string
+	Variants     []string
+	Season       database.Season
+	Data         []DataRow
+	Weeks        int
+	BorderSize   float64
+	FooterHeight float64
+	ImageHeight  float64
+	ImageWidth   float64
+	HeaderHeight float64
+	RowHeight    float64
+	PaddingSize  float64
+	LabelWidth   float64
+	NameWidth    float64
+	Rows         float64
+}
+
+func New(colorScheme, team string, season database.Season, weeks int, data []DataRow, variants ...string) Positions {
+	positions := Positions{
+		ColorScheme:  colorScheme,
+		Team:         team,
+		Variants:     variants,
+		Season:       season,
+		Data:         data,
+		Weeks:        weeks,
+		BorderSize:   float64(2),
+		FooterHeight: float64(14),
+		ImageWidth:   float64(816),
+		HeaderHeight: float64(24),
+		RowHeight:    float64(24),
+		PaddingSize:  float64(3),
+		LabelWidth:   float64(36),
+		NameWidth:    float64(180),
+		Rows:         float64(len(data)),
+	}
+	positions.ImageHeight = positions.Rows*positions.RowHeight + positions.RowHeight + positions.HeaderHeight + positions.PaddingSize*3
+	return positions
+}
+
+func IsAvailable(colorScheme string, seasonID int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "positions", seasonID, -1, team, variants...)
+}
+
+func Filename(seasonID int, team string, variants ...string) string {
+	return image.ImageFilename("positions", seasonID, -1, team, variants...)
+}
+
+func (p *Positions) Filename() string {
+	return Filename(p.Season.SeasonID, p.Team, p.Variants...)
+}
+
+func (p *Positions) Draw() error {
+	positionsDraws.Inc()
+
+	// chart title
+	positionsTitle := fmt.Sprintf("%s - Championship Positions", p.Season.SeasonName)
+	if len(p.Season.SeasonName) > 64 {
+		positionsTitle = p.Season.SeasonName
+	}
+	positionsWeeksTitle := fmt.Sprintf("After %d week", p.Weeks)
+	if p.Weeks != 1 {
+		positionsWeeksTitle += "s" // plural
+	}
+
+	log.Infof("draw positions for [%s] - [%s]", positionsTitle, positionsWeeksTitle)
+
+	// colorizer
+	if len(p.ColorScheme) == 0 {
+		p.ColorScheme = p.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(p.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(p.ImageWidth), int(p.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, p.ImageWidth, p.HeaderHeight)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(p.ImageWidth/1.5, 0, p.ImageWidth/3, p.HeaderHeight)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw chart title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(positionsTitle, p.ImageWidth/3, p.HeaderHeight/2, 0.5, 0.5)
+	// draw weeks title
+	dc.DrawStringAnchored(positionsWeeksTitle, p.ImageWidth/2+p.ImageWidth/3, p.HeaderHeight/2, 0.5, 0.5)
+
+	// plot area
+	xPlotStart := p.PaddingSize + p.LabelWidth
+	xPlotEnd := p.ImageWidth - p.PaddingSize - p.NameWidth
+	yPlotStart := p.HeaderHeight + p.PaddingSize
+	weekWidth := xPlotEnd - xPlotStart // empty chart if there are no weeks yet
+	if p.Weeks > 0 {
+		weekWidth = weekWidth / float64(p.Weeks)
+	}
+	xWeek := func(week int) float64 {
+		return xPlotStart + float64(week)*weekWidth + weekWidth/2
+	}
+	yPosition := func(position int) float64 {
+		if position < 1 || float64(position) > p.Rows {
+			return yPlotStart + p.Rows*p.RowHeight // out of chart, below last row
+		}
+		return yPlotStart + float64(position-1)*p.RowHeight + p.RowHeight/2
+	}
+
+	// zebra pattern rows with position labels
+	for row := 0; row < int(p.Rows); row++ {
+		yPos := yPlotStart + float64(row)*p.RowHeight
+		dc.DrawRectangle(p.PaddingSize, yPos, p.ImageWidth-p.PaddingSize*2, p.RowHeight)
+		if row%2 == 0 {
+			color.TopNCellDarkerBG(dc)
+		} else {
+			color.TopNCellLighterBG(dc)
+		}
+		dc.Fill()
+
+		color.TopNCellPosition(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(fmt.Sprintf("%d.", row+1), p.PaddingSize*3, yPos+p.RowHeight/2, 0, 0.5)
+	}
+
+	// week column headers
+	yPos := yPlotStart + p.Rows*p.RowHeight + p.PaddingSize
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	for week := 0; week < p.Weeks; week++ {
+		xPos := xPlotStart + float64(week)*weekWidth
+		dc.DrawRectangle(xPos+1, yPos, weekWidth-2, p.RowHeight-p.PaddingSize*2)
+		color.TopNHeaderBG(dc)
+		dc.Fill()
+
+		color.TopNHeaderFG(dc)
+		dc.DrawStringAnchored(fmt.Sprintf("W%d", week+1), xPos+weekWidth/2, yPos+(p.RowHeight-p.PaddingSize*2)/2, 0.5, 0.5)
+	}
+
+	// are there any marked drivers? if so then the others get faded out a bit
+	var anyMarked bool
+	for _, data := range p.Data {
+		if data.Marked {
+			anyMarked = true
+		}
+	}
+
+	// draw the lines, in reverse order so the leaders end up on top
+	for d := len(p.Data) - 1; d >= 0; d-- {
+		data := p.Data[d]
+		rgb := palette[d%len(palette)]
+		alpha := 255
+		lineWidth := 2.5
+		if anyMarked && !data.Marked {
+			alpha = 90
+			lineWidth = 1.5
+		}
+		if data.Marked {
+			lineWidth = 4
+		}
+		dc.SetRGBA255(rgb[0], rgb[1], rgb[2], alpha)
+		dc.SetLineWidth(lineWidth)
+
+		for week := 1; week < len(data.Positions) && week < p.Weeks; week++ {
+			from := data.Positions[week-1]
+			to := data.Positions[week]
+			if from == 0 || to == 0 {
+				continue // not classified yet
+			}
+			// dash it if the driver is out of the chart
+			if float64(from) > p.Rows || float64(to) > p.Rows {
+				dc.SetDash(3, 3)
+			}
+			dc.DrawLine(xWeek(week-1), yPosition(from), xWeek(week), yPosition(to))
+			dc.Stroke()
+			dc.SetDash()
+		}
+		for week := 0; week < len(data.Positions) && week < p.Weeks; week++ {
+			position := data.Positions[week]
+			if position == 0 || float64(position) > p.Rows {
+				continue
+			}
+			dc.DrawCircle(xWeek(week), yPosition(position), lineWidth+1)
+			dc.Fill()
+		}
+	}
+
+	// driver names at their final position
+	for d, data := range p.Data {
+		if len(data.Positions) == 0 {
+			continue
+		}
+		position := data.Positions[len(data.Positions)-1]
+		if position == 0 || float64(position) > p.Rows {
+			continue
+		}
+		xPos := xPlotEnd + p.PaddingSize*2
+		yPos := yPosition(position)
+
+		rgb := palette[d%len(palette)]
+		dc.SetRGB255(rgb[0], rgb[1], rgb[2])
+		dc.DrawRectangle(xPos, yPos-p.RowHeight/4, p.PaddingSize*2, p.RowHeight/2)
+		dc.Fill()
+
+		color.TopNCellDriver(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		// marked driver?
+		if data.Marked {
+			color.TopNCellValueDanger(dc)
+			if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 11); err != nil {
+				return fmt.Errorf("could not load font: %v", err)
+			}
+		}
+		dc.DrawStringAnchored(data.Driver, xPos+p.PaddingSize*4, yPos, 0, 0.5)
+	}
+
+	// add border to image
+	bdc := gg.NewContext(int(p.ImageWidth+p.BorderSize*2), int(p.ImageHeight+p.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(p.BorderSize), int(p.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(p.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := time.Now().Add(-2 * time.Hour).UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-p.FooterHeight/2, float64(bdc.Height())+p.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", p.FooterHeight/2, float64(bdc.Height())+p.FooterHeight/2, 0, 0.5)
+
+	if err := p.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(p.Filename()) // finally write to file
+}
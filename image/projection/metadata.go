@@ -0,0 +1,22 @@
+package projection
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (p *Projection) MetadataFilename() string {
+	return image.MetadataFilename("projection", p.Season.SeasonID, -1, p.Team, p.Variants...)
+}
+
+func (p *Projection) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(p.MetadataFilename())
+}
+
+func (p *Projection) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(p.ColorScheme, "projection",
+		p.Season.SeasonID, -1,
+		p.Season.SeasonName, p.Season.Year, p.Season.Quarter,
+		"projection", p.Team, p.Season.StartDate, p.Variants...,
+	)
+}
@@ -0,0 +1,289 @@
+package projection
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	projectionDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_projections_drawn_total",
+		Help: "Total championship projections drawn by iRvisualizer.",
+	})
+)
+
+type DataRow struct {
+	Driver        string
+	Points        int
+	BestPoints    int
+	WorstPoints   int
+	BestPosition  int
+	WorstPosition int
+	Marked        bool
+}
+
+type Projection struct {
+	ColorScheme  string
+	Team         string
+	Variants     []string
+	Season       database.Season
+	Weeks        int
+	Remaining    int
+	Data         []DataRow
+	BorderSize   float64
+	FooterHeight float64
+	ImageHeight  float64
+	ImageWidth   float64
+	HeaderHeight float64
+	RowHeight    float64
+	PaddingSize  float64
+	Rows         float64
+}
+
+func New(colorScheme, team string, season database.Season, weeks, remaining int, data []DataRow, variants ...string) Projection {
+	projection := Projection{
+		ColorScheme:  colorScheme,
+		Team:         team,
+		Variants:     variants,
+		Season:       season,
+		Weeks:        weeks,
+		Remaining:    remaining,
+		Data:         data,
+		BorderSize:   float64(2),
+		FooterHeight: float64(14),
+		ImageWidth:   float64(816),
+		HeaderHeight: float64(24),
+		RowHeight:    float64(18),
+		PaddingSize:  float64(3),
+		Rows:         float64(len(data)),
+	}
+	projection.ImageHeight = projection.Rows*projection.RowHeight + projection.RowHeight + projection.HeaderHeight + projection.PaddingSize*3
+	return projection
+}
+
+func IsAvailable(colorScheme string, seasonID int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "projection", seasonID, -1, team, variants...)
+}
+
+func Filename(seasonID int, team string, variants ...string) string {
+	return image.ImageFilename("projection", seasonID, -1, team, variants...)
+}
+
+func (p *Projection) Filename() string {
+	return Filename(p.Season.SeasonID, p.Team, p.Variants...)
+}
+
+func (p *Projection) Draw() error {
+	projectionDraws.Inc()
+
+	// projection title
+	projectionTitle := fmt.Sprintf("%s - Championship Projection", p.Season.SeasonName)
+	if len(p.Season.SeasonName) > 52 {
+		projectionTitle = p.Season.SeasonName
+	}
+	projectionWeeksTitle := fmt.Sprintf("%d week", p.Remaining)
+	if p.Remaining != 1 {
+		projectionWeeksTitle += "s" // plural
+	}
+	projectionWeeksTitle += " remaining"
+
+	log.Infof("draw projection for [%s] - [%s]", projectionTitle, projectionWeeksTitle)
+
+	// colorizer
+	if len(p.ColorScheme) == 0 {
+		p.ColorScheme = p.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(p.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(p.ImageWidth), int(p.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, p.ImageWidth, p.HeaderHeight)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(p.ImageWidth/1.5, 0, p.ImageWidth/3, p.HeaderHeight)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw projection title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(projectionTitle, p.ImageWidth/3, p.HeaderHeight/2, 0.5, 0.5)
+	// draw remaining weeks title
+	dc.DrawStringAnchored(projectionWeeksTitle, p.ImageWidth/2+p.ImageWidth/3, p.HeaderHeight/2, 0.5, 0.5)
+
+	// columns
+	xLength := p.ImageWidth - p.PaddingSize*2
+	xPos := p.PaddingSize
+	xPosition := xPos + p.PaddingSize*2
+	xDriver := xPos + 36
+	xPoints := xPos + 260
+	xBarStart := xPos + 280
+	xBarEnd := xPos + xLength - 110
+	xFinal := xPos + xLength - p.PaddingSize*2
+
+	// scale of the points bars
+	maxPoints := 1
+	for _, data := range p.Data {
+		if data.BestPoints > maxPoints {
+			maxPoints = data.BestPoints
+		}
+	}
+	xBar := func(points int) float64 {
+		return xBarStart + (xBarEnd-xBarStart)*float64(points)/float64(maxPoints)
+	}
+
+	// draw the column header
+	yPos := p.HeaderHeight + p.PaddingSize
+	dc.DrawRectangle(xPos, yPos, xLength, p.RowHeight)
+	color.TopNHeaderBG(dc)
+	dc.Fill()
+
+	color.TopNHeaderFG(dc)
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	dc.DrawStringAnchored("Driver", xDriver, yPos+p.RowHeight/2, 0, 0.5)
+	dc.DrawStringAnchored("Points", xPoints, yPos+p.RowHeight/2, 1, 0.5)
+	dc.DrawStringAnchored("Worst / Best Case", xBarStart+(xBarEnd-xBarStart)/2, yPos+p.RowHeight/2, 0.5, 0.5)
+	dc.DrawStringAnchored("Final Position", xFinal, yPos+p.RowHeight/2, 1, 0.5)
+
+	// draw outline
+	color.TopNHeaderOutline(dc)
+	dc.DrawRectangle(xPos, yPos, xLength, p.RowHeight)
+	dc.SetLineWidth(1)
+	dc.Stroke()
+
+	// draw the rows
+	yPosColumnStart := yPos + p.RowHeight + p.PaddingSize
+	for d, data := range p.Data {
+		yPos := yPosColumnStart + float64(d)*p.RowHeight
+
+		// zebra pattern
+		dc.DrawRectangle(xPos, yPos, xLength, p.RowHeight)
+		if d%2 == 0 {
+			color.TopNCellDarkerBG(dc)
+		} else {
+			color.TopNCellLighterBG(dc)
+		}
+		// marked driver?
+		if data.Marked {
+			color.TopNHeaderBG(dc)
+		}
+		dc.Fill()
+
+		// position
+		color.TopNCellPosition(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(fmt.Sprintf("%d.", d+1), xPosition, yPos+p.RowHeight/2, 0, 0.5)
+
+		// name
+		color.TopNCellDriver(dc)
+		if data.Marked {
+			color.TopNHeaderFG(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.Driver, xDriver, yPos+p.RowHeight/2, 0, 0.5)
+
+		// current points
+		color.TopNCellValue(dc)
+		if data.Marked {
+			color.TopNHeaderFGDanger(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(fmt.Sprintf("%d", data.Points), xPoints, yPos+p.RowHeight/2, 1, 0.5)
+
+		// worst case bar, and the range up to the best case
+		barHeight := p.RowHeight - p.PaddingSize*2
+		dc.DrawRectangle(xBarStart, yPos+p.PaddingSize, xBar(data.WorstPoints)-xBarStart, barHeight)
+		color.TopNHeaderBG(dc)
+		if data.Marked {
+			color.TopNCellDarkerBG(dc)
+		}
+		dc.Fill()
+		dc.DrawRectangle(xBar(data.WorstPoints), yPos+p.PaddingSize, xBar(data.BestPoints)-xBar(data.WorstPoints), barHeight)
+		color.HeaderRightBG(dc)
+		dc.Fill()
+
+		if err := dc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		color.TopNHeaderFG(dc)
+		if xBar(data.WorstPoints)-xBarStart > 40 {
+			dc.DrawStringAnchored(fmt.Sprintf("%d", data.WorstPoints), xBar(data.WorstPoints)-p.PaddingSize, yPos+p.RowHeight/2, 1, 0.5)
+		}
+		color.TopNCellValue(dc)
+		dc.DrawStringAnchored(fmt.Sprintf("%d", data.BestPoints), xBar(data.BestPoints)+p.PaddingSize, yPos+p.RowHeight/2, 0, 0.5)
+
+		// possible final positions
+		color.TopNCellDriver(dc)
+		if data.Marked {
+			color.TopNHeaderFG(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		final := fmt.Sprintf("P%d", data.BestPosition)
+		if data.WorstPosition != data.BestPosition {
+			final = fmt.Sprintf("P%d - P%d", data.BestPosition, data.WorstPosition)
+		}
+		dc.DrawStringAnchored(final, xFinal, yPos+p.RowHeight/2, 1, 0.5)
+
+		// draw outline
+		color.TopNCellOutline(dc)
+		dc.DrawRectangle(xPos, yPos, xLength, p.RowHeight)
+		dc.SetLineWidth(0.5)
+		dc.Stroke()
+	}
+
+	// add border to image
+	bdc := gg.NewContext(int(p.ImageWidth+p.BorderSize*2), int(p.ImageHeight+p.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(p.BorderSize), int(p.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(p.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := time.Now().UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-p.FooterHeight/2, float64(bdc.Height())+p.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", p.FooterHeight/2, float64(bdc.Height())+p.FooterHeight/2, 0, 0.5)
+
+	if err := p.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(p.Filename()) // finally write to file
+}
@@ -22,17 +22,26 @@ var (
 )
 
 type DataRow struct {
-	Driver string
-	Value  string
-	Marked bool
+	Position int // shared by tied drivers, defaults to the row number if not set
+	Driver   string
+	Value    string
+	Marked   bool
+}
+
+// Section holds the championship and time trial standings of a single car class or division
+type Section struct {
+	Title     string
+	ChampData []DataRow
+	TTData    []DataRow
 }
 
 type Ranking struct {
 	ColorScheme  string
 	Team         string
+	Variants     []string
+	Category     string // optional track category of the standings, like "Oval"
 	Season       database.Season
-	ChampData    []DataRow
-	TTData       []DataRow
+	Sections     []Section
 	BorderSize   float64
 	FooterHeight float64
 	ImageHeight  float64
@@ -44,15 +53,17 @@ type Ranking struct {
 	TTColumns    float64
 	ColumnWidth  float64
 	Rows         float64
+	SideBySide   bool    // sections are drawn side by side instead of below each other
+	SectionsLine float64 // how many sections fit side by side into a single line
 }
 
-func New(colorScheme, team string, season database.Season, champdata, ttdata []DataRow) Ranking {
+func New(colorScheme, team string, season database.Season, sections []Section, variants ...string) Ranking {
 	ranking := Ranking{
 		ColorScheme:  colorScheme,
 		Team:         team,
+		Variants:     variants,
 		Season:       season,
-		ChampData:    champdata,
-		TTData:       ttdata,
+		Sections:     sections,
 		BorderSize:   float64(2),
 		FooterHeight: float64(14),
 		ImageWidth:   float64(816),
@@ -63,21 +74,59 @@ func New(colorScheme, team string, season database.Season, champdata, ttdata []D
 		TTColumns:    float64(1),
 		Rows:         float64(15),
 	}
+	if len(sections) > 1 {
+		ranking.Rows = float64(10) // less rows per car class
+	}
 	ranking.ColumnWidth = ranking.ImageWidth / (ranking.ChampColumns + ranking.TTColumns)
-	ranking.ImageHeight = float64(ranking.Rows)*ranking.DriverHeight + ranking.DriverHeight + ranking.HeaderHeight + ranking.PaddingSize*3
+	ranking.ImageHeight = ranking.HeaderHeight + ranking.PaddingSize + float64(len(sections))*ranking.sectionHeight()
+	return ranking
+}
+
+// NewSideBySide creates a ranking with the leaders of each section side by side,
+// the championship standings of all sections first and their time trial standings below
+func NewSideBySide(colorScheme, team string, season database.Season, sections []Section, variants ...string) Ranking {
+	ranking := New(colorScheme, team, season, sections, variants...)
+	ranking.SideBySide = true
+	ranking.Rows = float64(10)
+	ranking.SectionsLine = math.Min(float64(len(sections)), 4)
+	if ranking.SectionsLine < 1 {
+		ranking.SectionsLine = 1
+	}
+	lines := math.Ceil(float64(len(sections)) / ranking.SectionsLine)
+	ranking.ColumnWidth = ranking.ImageWidth / ranking.SectionsLine
+	ranking.ImageHeight = ranking.HeaderHeight + ranking.PaddingSize + lines*2*ranking.sectionHeight()
 	return ranking
 }
 
-func IsAvailable(colorScheme string, seasonID int, team string) bool {
-	return image.IsAvailable(colorScheme, "ranking", seasonID, -1, team)
+// WithoutTimeTrial removes the time trial standings, with the championship standings using the whole width instead
+func (r *Ranking) WithoutTimeTrial() {
+	r.TTColumns = 0
+	if r.SideBySide {
+		lines := math.Ceil(float64(len(r.Sections)) / r.SectionsLine)
+		r.ImageHeight = r.HeaderHeight + r.PaddingSize + lines*r.sectionHeight()
+		return
+	}
+	r.ChampColumns = 3
+	r.Rows = float64(10)
+	r.ColumnWidth = r.ImageWidth / r.ChampColumns
+	r.ImageHeight = r.HeaderHeight + r.PaddingSize + float64(len(r.Sections))*r.sectionHeight()
+}
+
+func IsAvailable(colorScheme string, seasonID int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "ranking", seasonID, -1, team, variants...)
 }
 
-func Filename(seasonID int, team string) string {
-	return image.ImageFilename("ranking", seasonID, -1, team)
+func Filename(seasonID int, team string, variants ...string) string {
+	return image.ImageFilename("ranking", seasonID, -1, team, variants...)
 }
 
 func (r *Ranking) Filename() string {
-	return Filename(r.Season.SeasonID, r.Team)
+	return Filename(r.Season.SeasonID, r.Team, r.Variants...)
+}
+
+// sectionHeight returns the height of a single section, column headers and rows
+func (r *Ranking) sectionHeight() float64 {
+	return r.Rows*r.DriverHeight + r.DriverHeight + r.PaddingSize*2
 }
 
 func (r *Ranking) Draw(num, ofTotal int) error {
@@ -85,6 +134,9 @@ func (r *Ranking) Draw(num, ofTotal int) error {
 
 	// ranking title
 	rankingTitle := fmt.Sprintf("%s - Standings", r.Season.SeasonName)
+	if len(r.Category) > 0 {
+		rankingTitle = fmt.Sprintf("%s - %s Standings", r.Season.SeasonName, r.Category)
+	}
 	if len(r.Season.SeasonName) > 64 {
 		rankingTitle = r.Season.SeasonName
 	}
@@ -129,41 +181,85 @@ func (r *Ranking) Draw(num, ofTotal int) error {
 	color.HeaderFG(dc)
 	dc.DrawStringAnchored(rankingBestOfTitle, r.ImageWidth/2+r.ImageWidth/3, r.HeaderHeight/2, 0.5, 0.5)
 
-	// adjust to header height
-	yPosColumnHeaderStart := r.HeaderHeight + r.PaddingSize
+	if r.SideBySide {
+		// draw each section side by side, with champ columns of all sections on top and TT columns below
+		lines := math.Ceil(float64(len(r.Sections)) / r.SectionsLine)
+		for s, section := range r.Sections {
+			column := math.Mod(float64(s), r.SectionsLine)
+			line := math.Floor(float64(s) / r.SectionsLine)
+			yPos := r.HeaderHeight + r.PaddingSize + line*r.sectionHeight()
+			if err := r.drawColumns(dc, color, section.Title, "trophy", section.ChampData, column, 1, yPos); err != nil {
+				return err
+			}
+			if r.TTColumns == 0 {
+				continue
+			}
+			yPos += lines * r.sectionHeight()
+			if err := r.drawColumns(dc, color, fmt.Sprintf("Time Trial - %s", section.Title), "crown", section.TTData, column, 1, yPos); err != nil {
+				return err
+			}
+		}
+	} else {
+		// draw each section, with champ columns on the left and TT column on the right
+		for s, section := range r.Sections {
+			yPos := r.HeaderHeight + r.PaddingSize + float64(s)*r.sectionHeight()
+
+			champTitle := "Series Championship"
+			if len(section.Title) > 0 {
+				champTitle = fmt.Sprintf("%s - %s", champTitle, section.Title)
+			}
+			if err := r.drawColumns(dc, color, champTitle, "trophy", section.ChampData, 0, r.ChampColumns, yPos); err != nil {
+				return err
+			}
+			if r.TTColumns == 0 {
+				continue
+			}
+			if err := r.drawColumns(dc, color, "Time Trial", "crown", section.TTData, r.ChampColumns, r.TTColumns, yPos); err != nil {
+				return err
+			}
+		}
+	}
 
-	// draw the champ column headers
-	xChampLength := r.ColumnWidth*r.ChampColumns - r.PaddingSize*2
-	xPos := r.PaddingSize
-	yPos := yPosColumnHeaderStart
+	// add border to image
+	bdc := gg.NewContext(int(r.ImageWidth+r.BorderSize*2), int(r.ImageHeight+r.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(r.BorderSize), int(r.BorderSize))
 
-	// add column header
-	dc.DrawRectangle(xPos, yPos, xChampLength, r.DriverHeight)
-	color.TopNHeaderBG(dc)
-	dc.Fill()
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(r.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := time.Now().Add(-2 * time.Hour).UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-r.FooterHeight/2, float64(bdc.Height())+r.FooterHeight/2, 1, 0.5)
 
-	color.TopNHeaderFG(dc)
-	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
 		return fmt.Errorf("could not load font: %v", err)
 	}
-	dc.DrawStringAnchored("Series Championship", xPos+xChampLength/2, yPos+r.DriverHeight/2, 0.5, 0.5)
+	fdc.DrawStringAnchored("by Fabio Berchtold", r.FooterHeight/2, float64(bdc.Height())+r.FooterHeight/2, 0, 0.5)
 
-	// draw outline
-	color.TopNHeaderOutline(dc)
-	dc.MoveTo(xPos, yPos)
-	dc.LineTo(xPos+xChampLength, yPos)
-	dc.LineTo(xPos+xChampLength, yPos+r.DriverHeight)
-	dc.LineTo(xPos, yPos+r.DriverHeight)
-	dc.LineTo(xPos, yPos)
-	dc.SetLineWidth(1)
-	dc.Stroke()
+	if err := r.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(r.Filename()) // finally write to file
+}
 
-	// draw the TT column headers
-	xTTLength := r.ColumnWidth*r.TTColumns - r.PaddingSize*2
-	xPos = r.PaddingSize + float64(r.ChampColumns)*r.ColumnWidth
+// drawColumns draws a column header and its data rows, spread over multiple columns
+func (r *Ranking) drawColumns(dc *gg.Context, color scheme.Colorizer, title, icons string, data []DataRow, firstColumn, columns, yPosColumnHeaderStart float64) error {
+	// draw the column header
+	xHeaderLength := r.ColumnWidth*columns - r.PaddingSize*2
+	xPos := r.PaddingSize + firstColumn*r.ColumnWidth
+	yPos := yPosColumnHeaderStart
 
 	// add column header
-	dc.DrawRectangle(xPos, yPos, xTTLength, r.DriverHeight)
+	dc.DrawRectangle(xPos, yPos, xHeaderLength, r.DriverHeight)
 	color.TopNHeaderBG(dc)
 	dc.Fill()
 
@@ -171,29 +267,28 @@ func (r *Ranking) Draw(num, ofTotal int) error {
 	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
 		return fmt.Errorf("could not load font: %v", err)
 	}
-	dc.DrawStringAnchored("Time Trial", xPos+xTTLength/2, yPos+r.DriverHeight/2, 0.5, 0.5)
+	dc.DrawStringAnchored(title, xPos+xHeaderLength/2, yPos+r.DriverHeight/2, 0.5, 0.5)
 
 	// draw outline
 	color.TopNHeaderOutline(dc)
 	dc.MoveTo(xPos, yPos)
-	dc.LineTo(xPos+xTTLength, yPos)
-	dc.LineTo(xPos+xTTLength, yPos+r.DriverHeight)
+	dc.LineTo(xPos+xHeaderLength, yPos)
+	dc.LineTo(xPos+xHeaderLength, yPos+r.DriverHeight)
 	dc.LineTo(xPos, yPos+r.DriverHeight)
 	dc.LineTo(xPos, yPos)
 	dc.SetLineWidth(1)
 	dc.Stroke()
 
-	// draw the champ columns & rows
+	// draw the columns & rows
 	xLength := r.ColumnWidth - r.PaddingSize*2
 	yPosColumnStart := yPosColumnHeaderStart + r.DriverHeight + r.PaddingSize
-	var previousValue string
-	for d, data := range r.ChampData {
-		if float64(d) >= r.Rows*r.ChampColumns {
+	for d, data := range data {
+		if float64(d) >= r.Rows*columns {
 			break // abort if too many data rows supplied
 		}
 		column := math.Floor(float64(d) / r.Rows) // calculate current column based on row index / how many rows per column
 		row := float64(d) - (column * r.Rows)     // calculate on which row index of current column
-		xPos := r.PaddingSize + column*r.ColumnWidth
+		xPos := r.PaddingSize + (column+firstColumn)*r.ColumnWidth
 		yPos := yPosColumnStart + float64(row)*r.DriverHeight
 
 		// zebra pattern
@@ -214,108 +309,27 @@ func (r *Ranking) Draw(num, ofTotal int) error {
 		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
 			return fmt.Errorf("could not load font: %v", err)
 		}
-		if data.Value != previousValue {
-			previousValue = data.Value
-			// draw trophies
-			if d <= 2 {
-				// load icon
-				iconColor := "gold"
-				if d == 1 {
-					iconColor = "silver"
-				}
-				if d == 2 {
-					iconColor = "bronze"
-				}
-				icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/trophy_%s.png", iconColor))
-				if err != nil {
-					return fmt.Errorf("could not load icon: %v", err)
-				}
-				dc.DrawImage(icon, int(xPos+r.PaddingSize), int(yPos))
-			} else {
-				dc.DrawStringAnchored(fmt.Sprintf("%d.", int(row+1)+int(r.Rows*(column))), xPos+r.PaddingSize*2, yPos+r.DriverHeight/2, 0, 0.5)
-			}
-		}
-		// name
-		color.TopNCellDriver(dc)
-		// marked driver?
-		if data.Marked {
-			color.TopNHeaderFG(dc)
-		}
-		if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 11); err != nil {
-			return fmt.Errorf("could not load font: %v", err)
-		}
-		dc.DrawStringAnchored(data.Driver, xPos+20+r.PaddingSize*2, yPos+r.DriverHeight/2, 0, 0.5)
-		// value
-		color.TopNCellValue(dc)
-		// marked driver?
-		if data.Marked {
-			color.TopNHeaderFGDanger(dc)
-		}
-		if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 12); err != nil {
-			return fmt.Errorf("could not load font: %v", err)
-		}
-		dc.DrawStringAnchored(data.Value, xPos+xLength-r.PaddingSize*2, yPos+r.DriverHeight/2, 1, 0.5)
-
-		// draw outline
-		color.TopNCellOutline(dc)
-		dc.MoveTo(xPos, yPos)
-		dc.LineTo(xPos+xLength, yPos)
-		dc.LineTo(xPos+xLength, yPos+r.DriverHeight)
-		dc.LineTo(xPos, yPos+r.DriverHeight)
-		dc.LineTo(xPos, yPos)
-		dc.SetLineWidth(0.5)
-		dc.Stroke()
-	}
-
-	// draw the TT columns & rows
-	previousValue = ""
-	for d, data := range r.TTData {
-		if float64(d) >= r.Rows*r.TTColumns {
-			break // abort if too many data rows supplied
-		}
-		column := math.Floor(float64(d) / r.Rows) // calculate current column based on row index / how many rows per column
-		row := float64(d) - (column * r.Rows)     // calculate on which row index of current column
-		xPos := r.PaddingSize + (column+r.ChampColumns)*r.ColumnWidth
-		yPos := yPosColumnStart + float64(row)*r.DriverHeight
-
-		// zebra pattern
-		dc.DrawRectangle(xPos, yPos, xLength, r.DriverHeight)
-		if int(row)%2 == 0 {
-			color.TopNCellDarkerBG(dc)
-		} else {
-			color.TopNCellLighterBG(dc)
-		}
-		// marked driver?
-		if data.Marked {
-			color.TopNHeaderBG(dc)
-		}
-		dc.Fill()
-
-		// position
-		color.TopNCellPosition(dc)
-		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
-			return fmt.Errorf("could not load font: %v", err)
+		position := data.Position
+		if position == 0 {
+			position = d + 1
 		}
-		if data.Value != previousValue {
-			previousValue = data.Value
-			// draw trophies
-			if d <= 2 {
-				// load icon
-				iconColor := "gold"
-				if d == 1 {
-					iconColor = "silver"
-				}
-				if d == 2 {
-					iconColor = "bronze"
-				}
-				icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/crown_%s.png", iconColor))
-				if err != nil {
-					return fmt.Errorf("could not load icon: %v", err)
-				}
-				dc.DrawImage(icon, int(xPos+r.PaddingSize), int(yPos))
-			} else {
-				dc.DrawStringAnchored(fmt.Sprintf("%d.", int(row+1)+int(r.Rows*(column))), xPos+r.PaddingSize*2, yPos+r.DriverHeight/2, 0, 0.5)
+		// draw trophies
+		if position <= 3 {
+			// load icon
+			iconColor := "gold"
+			if position == 2 {
+				iconColor = "silver"
 			}
+			if position == 3 {
+				iconColor = "bronze"
+			}
+			icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/%s_%s.png", icons, iconColor))
+			if err != nil {
+				return fmt.Errorf("could not load icon: %v", err)
+			}
+			dc.DrawImage(icon, int(xPos+r.PaddingSize), int(yPos))
+		} else {
+			dc.DrawStringAnchored(fmt.Sprintf("%d.", position), xPos+r.PaddingSize*2, yPos+r.DriverHeight/2, 0, 0.5)
 		}
 		// name
 		color.TopNCellDriver(dc)
@@ -348,34 +362,5 @@ func (r *Ranking) Draw(num, ofTotal int) error {
 		dc.SetLineWidth(0.5)
 		dc.Stroke()
 	}
-
-	// add border to image
-	bdc := gg.NewContext(int(r.ImageWidth+r.BorderSize*2), int(r.ImageHeight+r.BorderSize*2))
-	color.Border(bdc)
-	bdc.Clear()
-	bdc.DrawImage(dc.Image(), int(r.BorderSize), int(r.BorderSize))
-
-	// add footer to image
-	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(r.FooterHeight))
-	color.Transparent(fdc)
-	fdc.Clear()
-	fdc.DrawImage(bdc.Image(), 0, 0)
-	// add last-update text
-	color.LastUpdate(fdc)
-	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
-		return fmt.Errorf("could not load font: %v", err)
-	}
-	lastUpdate := time.Now().Add(-2 * time.Hour).UTC().Format("2006-01-02 15:04:05 -07 MST")
-	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-r.FooterHeight/2, float64(bdc.Height())+r.FooterHeight/2, 1, 0.5)
-
-	color.CreatedBy(fdc)
-	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
-		return fmt.Errorf("could not load font: %v", err)
-	}
-	fdc.DrawStringAnchored("by Fabio Berchtold", r.FooterHeight/2, float64(bdc.Height())+r.FooterHeight/2, 0, 0.5)
-
-	if err := r.WriteMetadata(); err != nil {
-		return err
-	}
-	return fdc.SavePNG(r.Filename()) // finally write to file
+	return nil
 }
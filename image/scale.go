@@ -0,0 +1,84 @@
+package image
+
+import (
+	"math"
+	"sort"
+
+	"github.com/JamesClonk/iRvisualizer/util"
+)
+
+const (
+	ScaleLinear   = "linear"
+	ScaleLog      = "log"
+	ScaleQuantile = "quantile"
+)
+
+func IsScale(scale string) bool {
+	return scale == ScaleLinear || scale == ScaleLog || scale == ScaleQuantile
+}
+
+// Scale maps values between min and max into a position (0.0 - 1.0) of a color scale
+type Scale struct {
+	Type   string
+	Min    float64
+	Max    float64
+	values []float64 // sorted values within min and max, for quantile scales
+}
+
+func NewScale(scale string, min, max float64, values []float64) Scale {
+	s := Scale{Type: scale, Min: min, Max: max, values: make([]float64, 0)}
+	for _, value := range values {
+		if value >= min && value <= max {
+			s.values = append(s.values, value)
+		}
+	}
+	sort.Float64s(s.values)
+	if s.Type == ScaleQuantile && len(s.values) < 2 {
+		s.Type = ScaleLinear // not enough values to figure out quantiles
+	}
+	return s
+}
+
+// Position returns the position of a value on the scale
+func (s Scale) Position(value float64) float64 {
+	if s.Max <= s.Min || value <= s.Min {
+		return 0
+	}
+	if value >= s.Max {
+		return 1
+	}
+	switch s.Type {
+	case ScaleLog:
+		return math.Log1p(value-s.Min) / math.Log1p(s.Max-s.Min)
+	case ScaleQuantile:
+		// fraction of all values below, interpolated between the closest ranks
+		rank := sort.SearchFloat64s(s.values, value)
+		if rank == 0 {
+			return 0
+		}
+		if rank == len(s.values) {
+			return 1
+		}
+		lower, upper := s.values[rank-1], s.values[rank]
+		fraction := 1.0
+		if upper > lower {
+			fraction = (value - lower) / (upper - lower)
+		}
+		return (float64(rank-1) + fraction) / float64(len(s.values)-1)
+	default:
+		return (value - s.Min) / (s.Max - s.Min)
+	}
+}
+
+// Value returns the value at a position of the scale, the inverse of Position
+func (s Scale) Value(position float64) float64 {
+	position = math.Max(0, math.Min(1, position))
+	switch s.Type {
+	case ScaleLog:
+		return s.Min + math.Expm1(position*math.Log1p(s.Max-s.Min))
+	case ScaleQuantile:
+		return util.Quantile(s.values, position)
+	default:
+		return s.Min + (s.Max-s.Min)*position
+	}
+}
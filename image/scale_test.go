@@ -0,0 +1,28 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Image_Scale(t *testing.T) {
+	linear := NewScale(ScaleLinear, 1000, 3000, nil)
+	assert.Equal(t, 0.0, linear.Position(500))
+	assert.Equal(t, 0.5, linear.Position(2000))
+	assert.Equal(t, 1.0, linear.Position(5000))
+	assert.Equal(t, 2000.0, linear.Value(0.5))
+
+	log := NewScale(ScaleLog, 0, 1000, nil)
+	assert.InDelta(t, 0.5, log.Position(30.6), 0.01)
+	assert.InDelta(t, 30.6, log.Value(log.Position(30.6)), 0.0001)
+
+	quantile := NewScale(ScaleQuantile, 0, 10000, []float64{1000, 1100, 1200, 5000, 9000})
+	assert.Equal(t, 0.0, quantile.Position(1000))
+	assert.Equal(t, 0.5, quantile.Position(1200))
+	assert.Equal(t, 0.75, quantile.Position(5000))
+	assert.Equal(t, 1200.0, quantile.Value(0.5))
+
+	// not enough values for quantiles
+	assert.Equal(t, ScaleLinear, NewScale(ScaleQuantile, 0, 100, []float64{50}).Type)
+}
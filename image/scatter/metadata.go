@@ -0,0 +1,22 @@
+package scatter
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (s *Scatter) MetadataFilename() string {
+	return image.MetadataFilename(s.Name, s.Season.SeasonID, s.Week.RaceWeek+1, s.Team, s.Variants...)
+}
+
+func (s *Scatter) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(s.MetadataFilename())
+}
+
+func (s *Scatter) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(s.ColorScheme, s.Name,
+		s.Season.SeasonID, s.Week.RaceWeek+1,
+		s.Season.SeasonName, s.Season.Year, s.Season.Quarter,
+		s.Track.Name, s.Team, s.Season.StartDate, s.Variants...,
+	)
+}
@@ -0,0 +1,326 @@
+package scatter
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/util"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	scatterDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_scatters_drawn_total",
+		Help: "Total scatter plots drawn by iRvisualizer.",
+	})
+)
+
+type DataPoint struct {
+	Driver string
+	X      float64
+	Y      float64
+	Marked bool
+}
+
+type Axis struct {
+	Title   string
+	Laptime bool // values are laptimes and need to be formatted as such
+}
+
+type Scatter struct {
+	ColorScheme  string
+	Team         string
+	Variants     []string
+	Name         string
+	Title        string
+	Season       database.Season
+	Week         database.RaceWeek
+	Track        database.Track
+	Enrichment   image.Enrichment // track and car details in the header
+	XAxis        Axis
+	YAxis        Axis
+	Data         []DataPoint
+	BorderSize   float64
+	FooterHeight float64
+	ImageHeight  float64
+	ImageWidth   float64
+	HeaderHeight float64
+	PlotHeight   float64
+	AxisSize     float64
+	PaddingSize  float64
+	Ticks        int
+}
+
+func New(name, title, colorScheme, team string, season database.Season, week database.RaceWeek, track database.Track, xAxis, yAxis Axis, data []DataPoint) Scatter {
+	scatter := Scatter{
+		ColorScheme:  colorScheme,
+		Team:         team,
+		Name:         name,
+		Title:        title,
+		Season:       season,
+		Week:         week,
+		Track:        track,
+		XAxis:        xAxis,
+		YAxis:        yAxis,
+		Data:         data,
+		BorderSize:   float64(2),
+		FooterHeight: float64(14),
+		ImageWidth:   float64(756),
+		HeaderHeight: float64(46),
+		PlotHeight:   float64(420),
+		AxisSize:     float64(64),
+		PaddingSize:  float64(3),
+		Ticks:        6,
+	}
+	scatter.ImageHeight = scatter.HeaderHeight + scatter.PaddingSize*4 + scatter.PlotHeight + scatter.AxisSize/2
+	return scatter
+}
+
+func IsAvailable(colorScheme, name string, seasonID, week int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, name, seasonID, week, team, variants...)
+}
+
+func Filename(name string, seasonID, week int, team string, variants ...string) string {
+	return image.ImageFilename(name, seasonID, week, team, variants...)
+}
+
+func (s *Scatter) Filename() string {
+	return Filename(s.Name, s.Season.SeasonID, s.Week.RaceWeek+1, s.Team, s.Variants...)
+}
+
+func (a Axis) format(value float64) string {
+	if a.Laptime {
+		return util.ConvertLaptime(database.Laptime(value))
+	}
+	return fmt.Sprintf("%.0f", value)
+}
+
+// returns the range of values to plot, with a bit of space added on both ends
+func valueRange(values []float64) (float64, float64) {
+	min := math.MaxFloat64
+	max := -math.MaxFloat64
+	for _, value := range values {
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+	}
+	if len(values) == 0 {
+		return 0, 1
+	}
+	if max <= min {
+		return min - 1, max + 1
+	}
+	margin := (max - min) * 0.05
+	return min - margin, max + margin
+}
+
+func (s *Scatter) Draw() error {
+	scatterDraws.Inc()
+
+	// scatter titles, season + track
+	scatterTitle := fmt.Sprintf("%s - %s", s.Season.SeasonName, s.Title)
+	if len(s.Season.SeasonName) > 64 {
+		scatterTitle = s.Season.SeasonName
+	}
+	scatterWeekTitle := fmt.Sprintf("Week %d", s.Week.RaceWeek+1)
+	scatterTrackTitle := s.Enrichment.TrackTitle(s.Track)
+
+	log.Infof("draw scatter plot for [%s] - [%s]", scatterTitle, scatterTrackTitle)
+
+	// calculate value ranges and regression line
+	xs := make([]float64, 0)
+	ys := make([]float64, 0)
+	for _, point := range s.Data {
+		xs = append(xs, point.X)
+		ys = append(ys, point.Y)
+	}
+	xMin, xMax := valueRange(xs)
+	yMin, yMax := valueRange(ys)
+	slope, intercept := util.LinearRegression(xs, ys)
+
+	// colorizer
+	if len(s.ColorScheme) == 0 {
+		s.ColorScheme = s.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(s.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(s.ImageWidth), int(s.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, s.ImageWidth, s.HeaderHeight/2)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(0, s.HeaderHeight/2, s.ImageWidth, s.HeaderHeight/2)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw season title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(scatterTitle, s.PaddingSize*3, s.HeaderHeight/4, 0, 0.5)
+	// draw week title
+	dc.DrawStringAnchored(scatterWeekTitle, s.ImageWidth/4, s.HeaderHeight/4*3, 0.5, 0.5)
+	// draw track map and car logos, if enabled
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	assetsWidth := s.Enrichment.DrawAssets(dc, s.Track, s.ImageWidth-s.PaddingSize, s.HeaderHeight/2, s.HeaderHeight/2)
+	// draw track title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(scatterTrackTitle, s.ImageWidth/3*2-assetsWidth/2, s.HeaderHeight/4*3, 0.5, 0.5)
+
+	// plot area
+	xPlotStart := s.AxisSize + s.PaddingSize
+	xPlotLength := s.ImageWidth - xPlotStart - s.PaddingSize*3
+	yPlotStart := s.HeaderHeight + s.PaddingSize*2
+	yPlotLength := s.PlotHeight
+	dc.DrawRectangle(xPlotStart, yPlotStart, xPlotLength, yPlotLength)
+	color.TopNCellDarkerBG(dc)
+	dc.Fill()
+
+	// map values onto the plot area
+	xValue := func(value float64) float64 {
+		return xPlotStart + (value-xMin)/(xMax-xMin)*xPlotLength
+	}
+	yValue := func(value float64) float64 {
+		return yPlotStart + yPlotLength - (value-yMin)/(yMax-yMin)*yPlotLength
+	}
+
+	// grid lines and tick labels
+	if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	for tick := 0; tick <= s.Ticks; tick++ {
+		xTick := xMin + (xMax-xMin)/float64(s.Ticks)*float64(tick)
+		yTick := yMin + (yMax-yMin)/float64(s.Ticks)*float64(tick)
+
+		color.TopNCellOutline(dc)
+		dc.SetLineWidth(0.5)
+		dc.DrawLine(xValue(xTick), yPlotStart, xValue(xTick), yPlotStart+yPlotLength)
+		dc.DrawLine(xPlotStart, yValue(yTick), xPlotStart+xPlotLength, yValue(yTick))
+		dc.Stroke()
+
+		anchor := 0.5
+		if tick == s.Ticks {
+			anchor = 1
+		}
+		color.TopNCellPosition(dc)
+		dc.DrawStringAnchored(s.XAxis.format(xTick), xValue(xTick), yPlotStart+yPlotLength+s.PaddingSize*2, anchor, 1)
+		dc.DrawStringAnchored(s.YAxis.format(yTick), xPlotStart-s.PaddingSize*2, yValue(yTick), 1, 0.5)
+	}
+
+	// draw plot outline
+	color.TopNHeaderOutline(dc)
+	dc.DrawRectangle(xPlotStart, yPlotStart, xPlotLength, yPlotLength)
+	dc.SetLineWidth(1)
+	dc.Stroke()
+
+	// draw axis titles
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.TopNCellDriver(dc)
+	dc.DrawStringAnchored(s.XAxis.Title, xPlotStart+xPlotLength/2, s.ImageHeight-s.PaddingSize*2, 0.5, 0)
+	dc.Push()
+	dc.RotateAbout(gg.Radians(-90), s.PaddingSize*4, yPlotStart+yPlotLength/2)
+	dc.DrawStringAnchored(s.YAxis.Title, s.PaddingSize*4, yPlotStart+yPlotLength/2, 0.5, 0.5)
+	dc.Pop()
+
+	// draw regression line, clipped to the plot area
+	if len(s.Data) > 1 {
+		dc.Push()
+		dc.DrawRectangle(xPlotStart, yPlotStart, xPlotLength, yPlotLength)
+		dc.Clip()
+		color.TopNHeaderFGDanger(dc)
+		dc.SetLineWidth(1.5)
+		dc.SetDash(6, 4)
+		dc.DrawLine(xValue(xMin), yValue(slope*xMin+intercept), xValue(xMax), yValue(slope*xMax+intercept))
+		dc.Stroke()
+		dc.SetDash()
+		dc.ResetClip()
+		dc.Pop()
+	}
+
+	// draw data points, unmarked first so that marked drivers are always on top
+	color.TopNCellValue(dc)
+	for _, point := range s.Data {
+		if point.Marked {
+			continue
+		}
+		dc.DrawCircle(xValue(point.X), yValue(point.Y), 2.5)
+		dc.Fill()
+	}
+	if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	for _, point := range s.Data {
+		if !point.Marked {
+			continue
+		}
+		color.TopNCellValueDanger(dc)
+		dc.DrawCircle(xValue(point.X), yValue(point.Y), 4)
+		dc.Fill()
+
+		// put name on the side of the point that is facing the center of the plot
+		anchor := 0.0
+		xPos := xValue(point.X) + s.PaddingSize*2
+		if xValue(point.X) > xPlotStart+xPlotLength/2 {
+			anchor = 1
+			xPos = xValue(point.X) - s.PaddingSize*2
+		}
+		color.TopNCellDriver(dc)
+		dc.DrawStringAnchored(point.Driver, xPos, yValue(point.Y), anchor, 0.5)
+	}
+
+	// draw number of drivers
+	if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.TopNCellPosition(dc)
+	dc.DrawStringAnchored(fmt.Sprintf("%d drivers", len(s.Data)), xPlotStart+s.PaddingSize*2, yPlotStart+s.PaddingSize*2, 0, 1)
+
+	// add border to image
+	bdc := gg.NewContext(int(s.ImageWidth+s.BorderSize*2), int(s.ImageHeight+s.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(s.BorderSize), int(s.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(s.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := s.Week.LastUpdate.UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-s.FooterHeight/2, float64(bdc.Height())+s.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", s.FooterHeight/2, float64(bdc.Height())+s.FooterHeight/2, 0, 0.5)
+
+	if err := s.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(s.Filename()) // finally write to file
+}
@@ -0,0 +1,22 @@
+package schedule
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (s *Schedule) MetadataFilename() string {
+	return image.MetadataFilename("schedule", s.Season.SeasonID, -1, "", s.Variants...)
+}
+
+func (s *Schedule) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(s.MetadataFilename())
+}
+
+func (s *Schedule) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(s.ColorScheme, "schedule",
+		s.Season.SeasonID, -1,
+		s.Season.SeasonName, s.Season.Year, s.Season.Quarter,
+		"schedule", "", s.Season.StartDate, s.Variants...,
+	)
+}
@@ -0,0 +1,260 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	scheduleDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_schedules_drawn_total",
+		Help: "Total season schedules drawn by iRvisualizer.",
+	})
+)
+
+type DataRow struct {
+	Week      int // 0-based raceweek
+	Start     time.Time
+	End       time.Time
+	Track     string
+	Category  string
+	RaceTimes string
+	Current   bool
+}
+
+type Schedule struct {
+	ColorScheme  string
+	Variants     []string
+	Season       database.Season
+	Zone         string
+	Data         []DataRow
+	BorderSize   float64
+	FooterHeight float64
+	ImageHeight  float64
+	ImageWidth   float64
+	HeaderHeight float64
+	RowHeight    float64
+	PaddingSize  float64
+	Rows         float64
+}
+
+func New(colorScheme string, season database.Season, zone string, data []DataRow, variants ...string) Schedule {
+	schedule := Schedule{
+		ColorScheme:  colorScheme,
+		Variants:     variants,
+		Season:       season,
+		Zone:         zone,
+		Data:         data,
+		BorderSize:   float64(2),
+		FooterHeight: float64(14),
+		ImageWidth:   float64(880),
+		HeaderHeight: float64(24),
+		RowHeight:    float64(22),
+		PaddingSize:  float64(3),
+		Rows:         float64(len(data)),
+	}
+	schedule.ImageHeight = schedule.Rows*schedule.RowHeight + schedule.RowHeight + schedule.HeaderHeight + schedule.PaddingSize*3
+	return schedule
+}
+
+func IsAvailable(colorScheme string, seasonID int, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "schedule", seasonID, -1, "", variants...)
+}
+
+func Filename(seasonID int, variants ...string) string {
+	return image.ImageFilename("schedule", seasonID, -1, "", variants...)
+}
+
+func (s *Schedule) Filename() string {
+	return Filename(s.Season.SeasonID, s.Variants...)
+}
+
+func (s *Schedule) Draw() error {
+	scheduleDraws.Inc()
+
+	// schedule title
+	scheduleTitle := fmt.Sprintf("%s - Schedule", s.Season.SeasonName)
+	if len(s.Season.SeasonName) > 64 {
+		scheduleTitle = s.Season.SeasonName
+	}
+	schedule2ndTitle := fmt.Sprintf("Season %d/%d, %d weeks", s.Season.Year, s.Season.Quarter, len(s.Data))
+
+	log.Infof("draw schedule for [%s] - [%s]", scheduleTitle, schedule2ndTitle)
+
+	// colorizer
+	if len(s.ColorScheme) == 0 {
+		s.ColorScheme = s.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(s.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(s.ImageWidth), int(s.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, s.ImageWidth, s.HeaderHeight)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(s.ImageWidth/1.6, 0, s.ImageWidth-s.ImageWidth/1.6, s.HeaderHeight)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw schedule title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(scheduleTitle, s.ImageWidth/3.2, s.HeaderHeight/2, 0.5, 0.5)
+	// draw season title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	dc.DrawStringAnchored(schedule2ndTitle, s.ImageWidth/1.6+(s.ImageWidth-s.ImageWidth/1.6)/2, s.HeaderHeight/2, 0.5, 0.5)
+
+	// columns
+	xLength := s.ImageWidth - s.PaddingSize*2
+	xPos := s.PaddingSize
+	xWeek := xPos + s.PaddingSize*2
+	xDates := xPos + 60
+	xTrack := xPos + 200
+	xCategory := xPos + xLength - 330
+	xRaceTimes := xPos + xLength - 240
+
+	// draw the column header
+	yPos := s.HeaderHeight + s.PaddingSize
+	dc.DrawRectangle(xPos, yPos, xLength, s.RowHeight)
+	color.TopNHeaderBG(dc)
+	dc.Fill()
+
+	color.TopNHeaderFG(dc)
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	dc.DrawStringAnchored("Week", xWeek, yPos+s.RowHeight/2, 0, 0.5)
+	dc.DrawStringAnchored("Dates", xDates, yPos+s.RowHeight/2, 0, 0.5)
+	dc.DrawStringAnchored("Track", xTrack, yPos+s.RowHeight/2, 0, 0.5)
+	dc.DrawStringAnchored("Category", xCategory, yPos+s.RowHeight/2, 0, 0.5)
+	dc.DrawStringAnchored(fmt.Sprintf("Race Times (%s)", s.Zone), xRaceTimes, yPos+s.RowHeight/2, 0, 0.5)
+
+	// draw outline
+	color.TopNHeaderOutline(dc)
+	dc.DrawRectangle(xPos, yPos, xLength, s.RowHeight)
+	dc.SetLineWidth(1)
+	dc.Stroke()
+
+	// draw the rows
+	yPosColumnStart := yPos + s.RowHeight + s.PaddingSize
+	for d, data := range s.Data {
+		yPos := yPosColumnStart + float64(d)*s.RowHeight
+
+		// zebra pattern
+		dc.DrawRectangle(xPos, yPos, xLength, s.RowHeight)
+		if d%2 == 0 {
+			color.TopNCellDarkerBG(dc)
+		} else {
+			color.TopNCellLighterBG(dc)
+		}
+		// current week?
+		if data.Current {
+			color.TopNHeaderBG(dc)
+		}
+		dc.Fill()
+
+		// week
+		color.TopNCellPosition(dc)
+		if data.Current {
+			color.TopNHeaderFG(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(fmt.Sprintf("%d.", data.Week+1), xWeek, yPos+s.RowHeight/2, 0, 0.5)
+
+		// dates
+		if err := dc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dates := fmt.Sprintf("%s - %s", data.Start.Format("Jan 02"), data.End.AddDate(0, 0, -1).Format("Jan 02"))
+		dc.DrawStringAnchored(dates, xDates, yPos+s.RowHeight/2, 0, 0.5)
+
+		// track
+		color.TopNCellDriver(dc)
+		if data.Current {
+			color.TopNHeaderFG(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		track := data.Track
+		for len(track) > 4 {
+			if width, _ := dc.MeasureString(track); width < xCategory-xTrack-s.PaddingSize*2 {
+				break
+			}
+			track = track[:len(track)-4] + "..." // shorten track name if it doesn't fit
+		}
+		dc.DrawStringAnchored(track, xTrack, yPos+s.RowHeight/2, 0, 0.5)
+
+		// category
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.Category, xCategory, yPos+s.RowHeight/2, 0, 0.5)
+
+		// race times
+		color.TopNCellValue(dc)
+		if data.Current {
+			color.TopNHeaderFG(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.RaceTimes, xRaceTimes, yPos+s.RowHeight/2, 0, 0.5)
+
+		// draw outline
+		color.TopNCellOutline(dc)
+		dc.DrawRectangle(xPos, yPos, xLength, s.RowHeight)
+		dc.SetLineWidth(0.5)
+		dc.Stroke()
+	}
+
+	// add border to image
+	bdc := gg.NewContext(int(s.ImageWidth+s.BorderSize*2), int(s.ImageHeight+s.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(s.BorderSize), int(s.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(s.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := time.Now().UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-s.FooterHeight/2, float64(bdc.Height())+s.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", s.FooterHeight/2, float64(bdc.Height())+s.FooterHeight/2, 0, 0.5)
+
+	if err := s.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(s.Filename()) // finally write to file
+}
@@ -5,7 +5,7 @@ import (
 )
 
 func (s *Summary) MetadataFilename() string {
-	return image.MetadataFilename("summary", s.Season.SeasonID, s.Week.RaceWeek+1, s.Team)
+	return image.MetadataFilename("summary", s.Season.SeasonID, s.Week.RaceWeek+1, s.Team, s.Variants...)
 }
 
 func (s *Summary) ReadMetadata() (meta image.Metadata) {
@@ -17,6 +17,6 @@ func (s *Summary) WriteMetadata() error {
 	return image.WriteMetadata(s.ColorScheme, "summary",
 		s.Season.SeasonID, s.Week.RaceWeek+1,
 		s.Season.SeasonName, s.Season.Year, s.Season.Quarter,
-		s.Track.Name, s.Team, s.Season.StartDate,
+		s.Track.Name, s.Team, s.Season.StartDate, s.Variants...,
 	)
 }
@@ -21,18 +21,22 @@ var (
 )
 
 type DataSet struct {
-	Summary database.Summary
-	Marked  bool
+	Position int // shared by tied drivers
+	Summary  database.Summary
+	Marked   bool
 }
 
 type Summary struct {
 	ColorScheme        string
 	Team               string
 	Name               string
+	Variants           []string
 	Season             database.Season
 	Week               database.RaceWeek
 	Track              database.Track
+	Enrichment         image.Enrichment // track and car details in the header
 	Data               []DataSet
+	Eligibility        string // optional note about the minimum participation, shown in the footer
 	BorderSize         float64
 	FooterHeight       float64
 	ImageHeight        float64
@@ -48,11 +52,12 @@ type Summary struct {
 	DriverColumnWidth  float64
 }
 
-func New(colorScheme, team string, season database.Season, week database.RaceWeek, track database.Track, data []DataSet) Summary {
+func New(colorScheme, team string, season database.Season, week database.RaceWeek, track database.Track, data []DataSet, variants ...string) Summary {
 	lap := Summary{
 		ColorScheme:        colorScheme,
 		Team:               team,
 		Name:               "summary",
+		Variants:           variants,
 		Season:             season,
 		Week:               week,
 		Track:              track,
@@ -74,16 +79,16 @@ func New(colorScheme, team string, season database.Season, week database.RaceWee
 	return lap
 }
 
-func IsAvailable(colorScheme string, seasonID, week int, team string) bool {
-	return image.IsAvailable(colorScheme, "summary", seasonID, week, team)
+func IsAvailable(colorScheme string, seasonID, week int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "summary", seasonID, week, team, variants...)
 }
 
-func Filename(seasonID, week int, team string) string {
-	return image.ImageFilename("summary", seasonID, week, team)
+func Filename(seasonID, week int, team string, variants ...string) string {
+	return image.ImageFilename("summary", seasonID, week, team, variants...)
 }
 
 func (s *Summary) Filename() string {
-	return Filename(s.Season.SeasonID, s.Week.RaceWeek+1, s.Team)
+	return Filename(s.Season.SeasonID, s.Week.RaceWeek+1, s.Team, s.Variants...)
 }
 
 func (s *Summary) Draw() error {
@@ -95,7 +100,7 @@ func (s *Summary) Draw() error {
 		summaryTitle = s.Season.SeasonName
 	}
 	summaryWeekTitle := fmt.Sprintf("Week %d", s.Week.RaceWeek+1)
-	summaryTrackTitle := s.Track.Name
+	summaryTrackTitle := s.Enrichment.TrackTitle(s.Track)
 
 	if s.Week.RaceWeek == -1 { // season summary
 		summaryTitle = s.Season.SeasonName
@@ -138,12 +143,17 @@ func (s *Summary) Draw() error {
 	}
 	color.HeaderFG(dc)
 	dc.DrawStringAnchored(summaryWeekTitle, s.ImageWidth/4, s.HeaderHeight/4*3, 0.5, 0.5)
+	// draw track map and car logos, if enabled
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	assetsWidth := s.Enrichment.DrawAssets(dc, s.Track, s.ImageWidth-s.PaddingSize, s.HeaderHeight/2, s.HeaderHeight/2)
 	// draw track title
 	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
 		return fmt.Errorf("could not load font: %v", err)
 	}
 	color.HeaderFG(dc)
-	dc.DrawStringAnchored(summaryTrackTitle, s.ImageWidth/3*2, s.HeaderHeight/4*3, 0.5, 0.5)
+	dc.DrawStringAnchored(summaryTrackTitle, s.ImageWidth/3*2-assetsWidth/2, s.HeaderHeight/4*3, 0.5, 0.5)
 
 	// adjust to header height
 	yPosColumnHeaderStart := s.HeaderHeight + s.PaddingSize
@@ -288,7 +298,11 @@ func (s *Summary) Draw() error {
 		if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 12); err != nil {
 			return fmt.Errorf("could not load font: %v", err)
 		}
-		dc.DrawStringAnchored(entry.Summary.Driver.Name, xPos, yPos+s.DriverHeight/2, 0, 0.5)
+		driver := entry.Summary.Driver.Name
+		if entry.Position > 0 {
+			driver = fmt.Sprintf("%d. %s", entry.Position, driver)
+		}
+		dc.DrawStringAnchored(driver, xPos, yPos+s.DriverHeight/2, 0, 0.5)
 
 		// draw summary
 		xColumnLength := s.SummaryColumnWidth - s.PaddingSize
@@ -374,6 +388,11 @@ func (s *Summary) Draw() error {
 		return fmt.Errorf("could not load font: %v", err)
 	}
 	fdc.DrawStringAnchored("by Fabio Berchtold", s.FooterHeight/2, float64(bdc.Height())+s.FooterHeight/2, 0, 0.5)
+	// add eligibility note next to it
+	if len(s.Eligibility) > 0 {
+		createdByWidth, _ := fdc.MeasureString("by Fabio Berchtold")
+		fdc.DrawStringAnchored(s.Eligibility, s.FooterHeight*1.5+createdByWidth, float64(bdc.Height())+s.FooterHeight/2, 0, 0.5)
+	}
 
 	if err := s.WriteMetadata(); err != nil {
 		return err
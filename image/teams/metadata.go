@@ -0,0 +1,22 @@
+package teams
+
+import (
+	"github.com/JamesClonk/iRvisualizer/image"
+)
+
+func (t *Teams) MetadataFilename() string {
+	return image.MetadataFilename("teams", t.Season.SeasonID, -1, t.Team, t.Variants...)
+}
+
+func (t *Teams) ReadMetadata() (meta image.Metadata) {
+	return image.GetMetadata(t.MetadataFilename())
+}
+
+func (t *Teams) WriteMetadata() error {
+	// image string, seasonID, week int, season string, year, quarter int, track string, startDate time.Time
+	return image.WriteMetadata(t.ColorScheme, "teams",
+		t.Season.SeasonID, -1,
+		t.Season.SeasonName, t.Season.Year, t.Season.Quarter,
+		"teams", t.Team, t.Season.StartDate, t.Variants...,
+	)
+}
@@ -0,0 +1,284 @@
+package teams
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/fogleman/gg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	teamsDraws = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "irvisualizer_team_rankings_drawn_total",
+		Help: "Total team rankings drawn by iRvisualizer.",
+	})
+)
+
+type DataRow struct {
+	Team    string
+	Value   string
+	Drivers []DataDriver // contributing drivers
+	Marked  bool
+}
+
+type DataDriver struct {
+	Driver string
+	Value  string
+}
+
+type Teams struct {
+	ColorScheme  string
+	Team         string
+	Variants     []string
+	Season       database.Season
+	Data         []DataRow
+	BorderSize   float64
+	FooterHeight float64
+	ImageHeight  float64
+	ImageWidth   float64
+	HeaderHeight float64
+	TeamHeight   float64
+	DriverHeight float64
+	PaddingSize  float64
+	Rows         float64
+}
+
+func New(colorScheme, team string, season database.Season, data []DataRow, variants ...string) Teams {
+	teams := Teams{
+		ColorScheme:  colorScheme,
+		Team:         team,
+		Variants:     variants,
+		Season:       season,
+		Data:         data,
+		BorderSize:   float64(2),
+		FooterHeight: float64(14),
+		ImageWidth:   float64(640),
+		HeaderHeight: float64(24),
+		TeamHeight:   float64(18),
+		DriverHeight: float64(14),
+		PaddingSize:  float64(3),
+		Rows:         float64(len(data)),
+	}
+	teams.ImageHeight = teams.Rows*(teams.TeamHeight+teams.DriverHeight) + teams.TeamHeight + teams.HeaderHeight + teams.PaddingSize*3
+	return teams
+}
+
+func IsAvailable(colorScheme string, seasonID int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "teams", seasonID, -1, team, variants...)
+}
+
+func Filename(seasonID int, team string, variants ...string) string {
+	return image.ImageFilename("teams", seasonID, -1, team, variants...)
+}
+
+func (t *Teams) Filename() string {
+	return Filename(t.Season.SeasonID, t.Team, t.Variants...)
+}
+
+func (t *Teams) Draw(bestDrivers, num, ofTotal int) error {
+	teamsDraws.Inc()
+
+	// team ranking title
+	teamsTitle := fmt.Sprintf("%s - Team Standings", t.Season.SeasonName)
+	if len(t.Season.SeasonName) > 64 {
+		teamsTitle = t.Season.SeasonName
+	}
+	teamsBestOfTitle := fmt.Sprintf("Best %d driver", bestDrivers)
+	if bestDrivers > 1 {
+		teamsBestOfTitle += "s" // plural
+	}
+	teamsBestOfTitle += fmt.Sprintf(", best %d out of %d week", num, ofTotal)
+	if ofTotal > 1 {
+		teamsBestOfTitle += "s" // plural
+	}
+
+	log.Infof("draw team ranking for [%s] - [%s]", teamsTitle, teamsBestOfTitle)
+
+	// colorizer
+	if len(t.ColorScheme) == 0 {
+		t.ColorScheme = t.Season.SeriesColorScheme // get series default if needed
+	}
+	color := scheme.Get(t.ColorScheme)
+
+	// create canvas
+	dc := gg.NewContext(int(t.ImageWidth), int(t.ImageHeight))
+
+	// background
+	color.Background(dc)
+	dc.Clear()
+
+	// header
+	dc.DrawRectangle(0, 0, t.ImageWidth, t.HeaderHeight)
+	color.HeaderLeftBG(dc)
+	dc.Fill()
+	dc.DrawRectangle(t.ImageWidth/1.6, 0, t.ImageWidth-t.ImageWidth/1.6, t.HeaderHeight)
+	color.HeaderRightBG(dc)
+	dc.Fill()
+
+	// draw team ranking title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	color.HeaderFG(dc)
+	dc.DrawStringAnchored(teamsTitle, t.ImageWidth/3.2, t.HeaderHeight/2, 0.5, 0.5)
+	// draw best-of title
+	if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	dc.DrawStringAnchored(teamsBestOfTitle, t.ImageWidth/1.6+(t.ImageWidth-t.ImageWidth/1.6)/2, t.HeaderHeight/2, 0.5, 0.5)
+
+	// adjust to header height
+	yPosColumnHeaderStart := t.HeaderHeight + t.PaddingSize
+
+	// draw the column header
+	xLength := t.ImageWidth - t.PaddingSize*2
+	xPos := t.PaddingSize
+	yPos := yPosColumnHeaderStart
+
+	dc.DrawRectangle(xPos, yPos, xLength, t.TeamHeight)
+	color.TopNHeaderBG(dc)
+	dc.Fill()
+
+	color.TopNHeaderFG(dc)
+	if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	dc.DrawStringAnchored("Team Championship", xPos+xLength/2, yPos+t.TeamHeight/2, 0.5, 0.5)
+
+	// draw outline
+	color.TopNHeaderOutline(dc)
+	dc.DrawRectangle(xPos, yPos, xLength, t.TeamHeight)
+	dc.SetLineWidth(1)
+	dc.Stroke()
+
+	// draw the rows
+	yPosColumnStart := yPosColumnHeaderStart + t.TeamHeight + t.PaddingSize
+	rowHeight := t.TeamHeight + t.DriverHeight
+	var previousValue string
+	for d, data := range t.Data {
+		yPos := yPosColumnStart + float64(d)*rowHeight
+
+		// zebra pattern
+		dc.DrawRectangle(xPos, yPos, xLength, rowHeight)
+		if d%2 == 0 {
+			color.TopNCellDarkerBG(dc)
+		} else {
+			color.TopNCellLighterBG(dc)
+		}
+		// marked team?
+		if data.Marked {
+			color.TopNHeaderBG(dc)
+		}
+		dc.Fill()
+
+		// position
+		color.TopNCellPosition(dc)
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		if data.Value != previousValue {
+			previousValue = data.Value
+			// draw trophies
+			if d <= 2 {
+				// load icon
+				iconColor := "gold"
+				if d == 1 {
+					iconColor = "silver"
+				}
+				if d == 2 {
+					iconColor = "bronze"
+				}
+				icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/trophy_%s.png", iconColor))
+				if err != nil {
+					return fmt.Errorf("could not load icon: %v", err)
+				}
+				dc.DrawImage(icon, int(xPos+t.PaddingSize), int(yPos+1))
+			} else {
+				dc.DrawStringAnchored(fmt.Sprintf("%d.", d+1), xPos+t.PaddingSize*2, yPos+t.TeamHeight/2, 0, 0.5)
+			}
+		}
+		// team name
+		color.TopNCellDriver(dc)
+		// marked team?
+		if data.Marked {
+			color.TopNHeaderFG(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.Team, xPos+20+t.PaddingSize*2, yPos+t.TeamHeight/2, 0, 0.5)
+
+		// contributing drivers
+		drivers := make([]string, 0)
+		for _, driver := range data.Drivers {
+			drivers = append(drivers, fmt.Sprintf("%s (%s)", driver.Driver, driver.Value))
+		}
+		if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		// shorten list of drivers if it doesn't fit into the row
+		driverList := strings.Join(drivers, ", ")
+		for len(drivers) > 1 {
+			if width, _ := dc.MeasureString(driverList); width < xLength-80-t.PaddingSize*4 {
+				break
+			}
+			drivers = drivers[:len(drivers)-1]
+			driverList = strings.Join(drivers, ", ") + ", ..."
+		}
+		dc.DrawStringAnchored(driverList, xPos+20+t.PaddingSize*2, yPos+t.TeamHeight+t.DriverHeight/2-2, 0, 0.5)
+
+		// value
+		color.TopNCellValue(dc)
+		// marked team?
+		if data.Marked {
+			color.TopNHeaderFGDanger(dc)
+		}
+		if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 14); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		dc.DrawStringAnchored(data.Value, xPos+xLength-t.PaddingSize*2, yPos+rowHeight/2, 1, 0.5)
+
+		// draw outline
+		color.TopNCellOutline(dc)
+		dc.DrawRectangle(xPos, yPos, xLength, rowHeight)
+		dc.SetLineWidth(0.5)
+		dc.Stroke()
+	}
+
+	// add border to image
+	bdc := gg.NewContext(int(t.ImageWidth+t.BorderSize*2), int(t.ImageHeight+t.BorderSize*2))
+	color.Border(bdc)
+	bdc.Clear()
+	bdc.DrawImage(dc.Image(), int(t.BorderSize), int(t.BorderSize))
+
+	// add footer to image
+	fdc := gg.NewContext(bdc.Width(), bdc.Height()+int(t.FooterHeight))
+	color.Transparent(fdc)
+	fdc.Clear()
+	fdc.DrawImage(bdc.Image(), 0, 0)
+	// add last-update text
+	color.LastUpdate(fdc)
+	if err := fdc.LoadFontFace("public/fonts/roboto-mono_light.ttf", 10); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	lastUpdate := time.Now().Add(-2 * time.Hour).UTC().Format("2006-01-02 15:04:05 -07 MST")
+	fdc.DrawStringAnchored(fmt.Sprintf("Last Update: %s", lastUpdate), float64(bdc.Width())-t.FooterHeight/2, float64(bdc.Height())+t.FooterHeight/2, 1, 0.5)
+
+	color.CreatedBy(fdc)
+	if err := fdc.LoadFontFace("public/fonts/Roboto-Light.ttf", 9); err != nil {
+		return fmt.Errorf("could not load font: %v", err)
+	}
+	fdc.DrawStringAnchored("by Fabio Berchtold", t.FooterHeight/2, float64(bdc.Height())+t.FooterHeight/2, 0, 0.5)
+
+	if err := t.WriteMetadata(); err != nil {
+		return err
+	}
+	return fdc.SavePNG(t.Filename()) // finally write to file
+}
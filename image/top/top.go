@@ -20,12 +20,14 @@ var (
 )
 
 type DataSet struct {
-	Title string
-	Icons string
-	Rows  []DataSetRow
+	Section string // optional section title, datasets of the same section are drawn side by side
+	Title   string
+	Icons   string
+	Rows    []DataSetRow
 }
 
 type DataSetRow struct {
+	Position     int // shared by tied drivers, defaults to the row number if not set
 	Driver       string
 	Value        string
 	Icon         string
@@ -37,10 +39,13 @@ type Top struct {
 	ColorScheme  string
 	Team         string
 	Name         string
+	Variants     []string
+	Enrichment   image.Enrichment
 	Season       database.Season
 	Week         database.RaceWeek
 	Track        database.Track
 	Data         []DataSet
+	Eligibility  string // optional note about the minimum participation, shown in the footer
 	BorderSize   float64
 	FooterHeight float64
 	ImageHeight  float64
@@ -67,30 +72,56 @@ func New(colorScheme, team, name string, season database.Season, week database.R
 		HeaderHeight: float64(24),
 		DriverHeight: float64(16),
 		PaddingSize:  float64(3),
-		Columns:      float64(len(data)),
+	}
+
+	top.ImageHeight = top.HeaderHeight + top.PaddingSize
+	for _, section := range top.sections() {
+		if float64(len(section)) > top.Columns {
+			top.Columns = float64(len(section))
+		}
+		top.ImageHeight += top.sectionHeight(section)
 	}
 	top.ColumnWidth = top.ImageWidth / top.Columns
+	return top
+}
+
+// sections groups consecutive datasets of the same section together
+func (t *Top) sections() [][]DataSet {
+	sections := make([][]DataSet, 0)
+	for d, data := range t.Data {
+		if d == 0 || data.Section != t.Data[d-1].Section {
+			sections = append(sections, make([]DataSet, 0))
+		}
+		sections[len(sections)-1] = append(sections[len(sections)-1], data)
+	}
+	return sections
+}
 
+// sectionHeight returns the height of a section, its title, column headers and rows
+func (t *Top) sectionHeight(section []DataSet) float64 {
 	maxRows := 0
-	for _, d := range data {
+	for _, d := range section {
 		if len(d.Rows) > maxRows {
 			maxRows = len(d.Rows)
 		}
 	}
-	top.ImageHeight = float64(maxRows)*top.DriverHeight + top.DriverHeight + top.HeaderHeight + top.PaddingSize*3
-	return top
+	height := float64(maxRows)*t.DriverHeight + t.DriverHeight + t.PaddingSize*2
+	if len(section) > 0 && len(section[0].Section) > 0 {
+		height += t.DriverHeight + t.PaddingSize
+	}
+	return height
 }
 
-func IsAvailable(colorScheme string, name string, seasonID, week int, team string) bool {
-	return image.IsAvailable(colorScheme, "top/"+name, seasonID, week, team)
+func IsAvailable(colorScheme string, name string, seasonID, week int, team string, variants ...string) bool {
+	return image.IsAvailable(colorScheme, "top/"+name, seasonID, week, team, variants...)
 }
 
-func Filename(name string, seasonID, week int, team string) string {
-	return image.ImageFilename("top/"+name, seasonID, week, team)
+func Filename(name string, seasonID, week int, team string, variants ...string) string {
+	return image.ImageFilename("top/"+name, seasonID, week, team, variants...)
 }
 
 func (t *Top) Filename() string {
-	return Filename(t.Name, t.Season.SeasonID, t.Week.RaceWeek+1, t.Team)
+	return Filename(t.Name, t.Season.SeasonID, t.Week.RaceWeek+1, t.Team, t.Variants...)
 }
 
 func (t *Top) Draw(headerless bool) error {
@@ -101,9 +132,9 @@ func (t *Top) Draw(headerless bool) error {
 	if len(t.Season.SeasonName) > 38 {
 		topTitle = t.Season.SeasonName
 	}
-	topTrackTitle := fmt.Sprintf("Week %d - %s", t.Week.RaceWeek+1, t.Track.Name)
-	if t.Week.RaceWeek == -1 { // seasonal avg. top
-		topTrackTitle = "Seasonal Average"
+	topTrackTitle := fmt.Sprintf("Week %d - %s", t.Week.RaceWeek+1, t.Enrichment.TrackTitle(t.Track))
+	if t.Week.RaceWeek == -1 { // seasonal top
+		topTrackTitle = "Whole Season"
 	}
 
 	log.Infof("draw top for [%s] - [%s]", topTitle, topTrackTitle)
@@ -142,84 +173,109 @@ func (t *Top) Draw(headerless bool) error {
 		}
 		color.HeaderFG(dc)
 		dc.DrawStringAnchored(topTitle, t.ImageWidth/4, t.HeaderHeight/2, 0.5, 0.5)
+		// draw track map and car logos, if enabled
+		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 10); err != nil {
+			return fmt.Errorf("could not load font: %v", err)
+		}
+		assetsWidth := t.Enrichment.DrawAssets(dc, t.Track, t.ImageWidth-t.PaddingSize, 0, t.HeaderHeight)
 		// draw track title
 		if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 14); err != nil {
 			return fmt.Errorf("could not load font: %v", err)
 		}
 		color.HeaderFG(dc)
-		dc.DrawStringAnchored(topTrackTitle, t.ImageWidth/2+t.ImageWidth/4, t.HeaderHeight/2, 0.5, 0.5)
+		dc.DrawStringAnchored(topTrackTitle, t.ImageWidth/2+(t.ImageWidth/2-assetsWidth)/2, t.HeaderHeight/2, 0.5, 0.5)
 
 		// adjust to header height
 		yPosColumnHeaderStart = t.HeaderHeight + t.PaddingSize
 	}
 
-	// draw the column headers
-	xLength := t.ColumnWidth - t.PaddingSize*2
-	for column, data := range t.Data {
-		xPos := t.PaddingSize + float64(column)*t.ColumnWidth
-		yPos := yPosColumnHeaderStart
+	// draw each section
+	yPosSectionStart := yPosColumnHeaderStart
+	for _, section := range t.sections() {
+		sectionHeight := t.sectionHeight(section)
 
-		// add column header
-		dc.DrawRectangle(xPos, yPos, xLength, t.DriverHeight)
-		color.TopNHeaderBG(dc)
-		dc.Fill()
+		// draw the section title
+		if len(section[0].Section) > 0 {
+			dc.DrawRectangle(t.PaddingSize, yPosSectionStart, t.ImageWidth-t.PaddingSize*2, t.DriverHeight)
+			color.HeaderRightBG(dc)
+			dc.Fill()
 
-		color.TopNHeaderFG(dc)
-		if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
-			return fmt.Errorf("could not load font: %v", err)
+			color.HeaderFG(dc)
+			if err := dc.LoadFontFace("public/fonts/Roboto-BoldItalic.ttf", 12); err != nil {
+				return fmt.Errorf("could not load font: %v", err)
+			}
+			dc.DrawStringAnchored(section[0].Section, t.ImageWidth/2, yPosSectionStart+t.DriverHeight/2, 0.5, 0.5)
+			yPosSectionStart += t.DriverHeight + t.PaddingSize
+			sectionHeight -= t.DriverHeight + t.PaddingSize
 		}
-		dc.DrawStringAnchored(data.Title, xPos+xLength/2, yPos+t.DriverHeight/2, 0.5, 0.5)
-
-		// draw outline
-		color.TopNHeaderOutline(dc)
-		dc.MoveTo(xPos, yPos)
-		dc.LineTo(xPos+xLength, yPos)
-		dc.LineTo(xPos+xLength, yPos+t.DriverHeight)
-		dc.LineTo(xPos, yPos+t.DriverHeight)
-		dc.LineTo(xPos, yPos)
-		dc.SetLineWidth(1)
-		dc.Stroke()
-	}
-
-	// draw the columns
-	yPosColumnStart := yPosColumnHeaderStart + t.DriverHeight + t.PaddingSize
-	for column, data := range t.Data {
-		xPos := t.PaddingSize + float64(column)*t.ColumnWidth
 
-		// rows
-		var previousValue string
-		for row, entry := range data.Rows {
-			yPos := yPosColumnStart + float64(row)*t.DriverHeight
+		// draw the column headers
+		xLength := t.ColumnWidth - t.PaddingSize*2
+		for column, data := range section {
+			xPos := t.PaddingSize + float64(column)*t.ColumnWidth
+			yPos := yPosSectionStart
 
-			// zebra pattern
+			// add column header
 			dc.DrawRectangle(xPos, yPos, xLength, t.DriverHeight)
-			if row%2 == 0 {
-				color.TopNCellDarkerBG(dc)
-			} else {
-				color.TopNCellLighterBG(dc)
-			}
-			// marked driver?
-			if entry.Marked {
-				color.TopNHeaderBG(dc)
-			}
+			color.TopNHeaderBG(dc)
 			dc.Fill()
 
-			// position
-			color.TopNCellPosition(dc)
-			if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
+			color.TopNHeaderFG(dc)
+			if err := dc.LoadFontFace("public/fonts/Roboto-Medium.ttf", 12); err != nil {
 				return fmt.Errorf("could not load font: %v", err)
 			}
-			if entry.Value != previousValue {
-				previousValue = entry.Value
+			dc.DrawStringAnchored(data.Title, xPos+xLength/2, yPos+t.DriverHeight/2, 0.5, 0.5)
+
+			// draw outline
+			color.TopNHeaderOutline(dc)
+			dc.MoveTo(xPos, yPos)
+			dc.LineTo(xPos+xLength, yPos)
+			dc.LineTo(xPos+xLength, yPos+t.DriverHeight)
+			dc.LineTo(xPos, yPos+t.DriverHeight)
+			dc.LineTo(xPos, yPos)
+			dc.SetLineWidth(1)
+			dc.Stroke()
+		}
+
+		// draw the columns
+		yPosColumnStart := yPosSectionStart + t.DriverHeight + t.PaddingSize
+		for column, data := range section {
+			xPos := t.PaddingSize + float64(column)*t.ColumnWidth
 
+			// rows
+			for row, entry := range data.Rows {
+				yPos := yPosColumnStart + float64(row)*t.DriverHeight
+
+				// zebra pattern
+				dc.DrawRectangle(xPos, yPos, xLength, t.DriverHeight)
+				if row%2 == 0 {
+					color.TopNCellDarkerBG(dc)
+				} else {
+					color.TopNCellLighterBG(dc)
+				}
+				// marked driver?
+				if entry.Marked {
+					color.TopNHeaderBG(dc)
+				}
+				dc.Fill()
+
+				// position
+				color.TopNCellPosition(dc)
+				if err := dc.LoadFontFace("public/fonts/Roboto-Light.ttf", 11); err != nil {
+					return fmt.Errorf("could not load font: %v", err)
+				}
+				position := entry.Position
+				if position == 0 {
+					position = row + 1
+				}
 				// draw icons if specified
-				if len(data.Icons) > 0 && row <= 2 {
+				if len(data.Icons) > 0 && position <= 3 {
 					// load icon
 					iconColor := "gold"
-					if row == 1 {
+					if position == 2 {
 						iconColor = "silver"
 					}
-					if row == 2 {
+					if position == 3 {
 						iconColor = "bronze"
 					}
 					icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/%s_%s.png", data.Icons, iconColor))
@@ -228,48 +284,50 @@ func (t *Top) Draw(headerless bool) error {
 					}
 					dc.DrawImage(icon, int(xPos+t.PaddingSize), int(yPos))
 				} else {
-					dc.DrawStringAnchored(fmt.Sprintf("%d.", row+1), xPos+t.PaddingSize*2, yPos+t.DriverHeight/2, 0, 0.5)
+					dc.DrawStringAnchored(fmt.Sprintf("%d.", position), xPos+t.PaddingSize*2, yPos+t.DriverHeight/2, 0, 0.5)
 				}
-			}
-			// name
-			color.TopNCellDriver(dc)
-			// marked driver?
-			if entry.Marked {
-				color.TopNHeaderFG(dc)
-			}
-			if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 11); err != nil {
-				return fmt.Errorf("could not load font: %v", err)
-			}
-			dc.DrawStringAnchored(entry.Driver, xPos+20+t.PaddingSize*2, yPos+t.DriverHeight/2, 0, 0.5)
-			// value
-			color.TopNCellValue(dc)
-			// marked driver?
-			if entry.Marked {
-				color.TopNHeaderFGDanger(dc)
-			}
-			if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 12); err != nil {
-				return fmt.Errorf("could not load font: %v", err)
-			}
-			dc.DrawStringAnchored(entry.Value, xPos+xLength-t.PaddingSize*2, yPos+t.DriverHeight/2, 1, 0.5)
-			// draw an icon if specified
-			if len(entry.Icon) > 0 {
-				icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/%s.png", entry.Icon))
-				if err != nil {
-					return fmt.Errorf("could not load icon: %v", err)
+				// name
+				color.TopNCellDriver(dc)
+				// marked driver?
+				if entry.Marked {
+					color.TopNHeaderFG(dc)
+				}
+				if err := dc.LoadFontFace("public/fonts/Roboto-Regular.ttf", 11); err != nil {
+					return fmt.Errorf("could not load font: %v", err)
+				}
+				dc.DrawStringAnchored(entry.Driver, xPos+20+t.PaddingSize*2, yPos+t.DriverHeight/2, 0, 0.5)
+				// value
+				color.TopNCellValue(dc)
+				// marked driver?
+				if entry.Marked {
+					color.TopNHeaderFGDanger(dc)
+				}
+				if err := dc.LoadFontFace("public/fonts/roboto-mono_regular.ttf", 12); err != nil {
+					return fmt.Errorf("could not load font: %v", err)
+				}
+				dc.DrawStringAnchored(entry.Value, xPos+xLength-t.PaddingSize*2, yPos+t.DriverHeight/2, 1, 0.5)
+				// draw an icon if specified
+				if len(entry.Icon) > 0 {
+					icon, err := gg.LoadPNG(fmt.Sprintf("public/icons/%s.png", entry.Icon))
+					if err != nil {
+						return fmt.Errorf("could not load icon: %v", err)
+					}
+					dc.DrawImageAnchored(icon, int(xPos+xLength-t.PaddingSize*2)-entry.IconPosition, int(yPos), 1, 0)
 				}
-				dc.DrawImageAnchored(icon, int(xPos+xLength-t.PaddingSize*2)-entry.IconPosition, int(yPos), 1, 0)
-			}
 
-			// draw outline
-			color.TopNCellOutline(dc)
-			dc.MoveTo(xPos, yPos)
-			dc.LineTo(xPos+xLength, yPos)
-			dc.LineTo(xPos+xLength, yPos+t.DriverHeight)
-			dc.LineTo(xPos, yPos+t.DriverHeight)
-			dc.LineTo(xPos, yPos)
-			dc.SetLineWidth(0.5)
-			dc.Stroke()
+				// draw outline
+				color.TopNCellOutline(dc)
+				dc.MoveTo(xPos, yPos)
+				dc.LineTo(xPos+xLength, yPos)
+				dc.LineTo(xPos+xLength, yPos+t.DriverHeight)
+				dc.LineTo(xPos, yPos+t.DriverHeight)
+				dc.LineTo(xPos, yPos)
+				dc.SetLineWidth(0.5)
+				dc.Stroke()
+			}
 		}
+
+		yPosSectionStart += sectionHeight
 	}
 
 	// add border to image
@@ -296,6 +354,11 @@ func (t *Top) Draw(headerless bool) error {
 		return fmt.Errorf("could not load font: %v", err)
 	}
 	fdc.DrawStringAnchored("by Fabio Berchtold", t.FooterHeight/2, float64(bdc.Height())+t.FooterHeight/2, 0, 0.5)
+	// add eligibility note next to it
+	if len(t.Eligibility) > 0 {
+		createdByWidth, _ := fdc.MeasureString("by Fabio Berchtold")
+		fdc.DrawStringAnchored(t.Eligibility, t.FooterHeight*1.5+createdByWidth, float64(bdc.Height())+t.FooterHeight/2, 0, 0.5)
+	}
 
 	if err := t.WriteMetadata(); err != nil {
 		return err
@@ -2,6 +2,7 @@ package image
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,10 +11,10 @@ import (
 	"github.com/JamesClonk/iRvisualizer/util"
 )
 
-func IsAvailable(colorScheme, image string, seasonID, week int, team string) bool {
+func IsAvailable(colorScheme, image string, seasonID, week int, team string, variants ...string) bool {
 	// check if file already exists
-	imageFilename := ImageFilename(image, seasonID, week, team)
-	metaFilename := MetadataFilename(image, seasonID, week, team)
+	imageFilename := ImageFilename(image, seasonID, week, team, variants...)
+	metaFilename := MetadataFilename(image, seasonID, week, team, variants...)
 	if util.FileExists(metaFilename) && util.FileExists(imageFilename) {
 		metadata := GetMetadata(metaFilename)
 		if metadata.ColorScheme != colorScheme && len(colorScheme) > 0 {
@@ -47,44 +48,79 @@ func IsAvailable(colorScheme, image string, seasonID, week int, team string) boo
 	return false // cached image needs to be regenerated
 }
 
-func ImageFilename(image string, seasonID, week int, team string) string {
+// ImageFilename returns the path of an image file, any non-empty variants (like different counting rules) are appended to its name
+func ImageFilename(image string, seasonID, week int, team string, variants ...string) string {
+	var suffix string
 	if len(team) > 0 {
-		team = strings.ReplaceAll(strings.ToLower(team), " ", "_")
-		if week <= 0 {
-			return fmt.Sprintf("public/%s/season_%d_%s.png", image, seasonID, team)
+		suffix += "_" + strings.ReplaceAll(strings.ToLower(team), " ", "_")
+	}
+	for _, variant := range variants {
+		if len(variant) > 0 {
+			suffix += "_" + sanitize(variant)
 		}
-		return fmt.Sprintf("public/%s/season_%d_week_%d_%s.png", image, seasonID, week, team)
 	}
 
 	if week <= 0 {
-		return fmt.Sprintf("public/%s/season_%d.png", image, seasonID)
+		return fmt.Sprintf("public/%s/season_%d%s.png", image, seasonID, suffix)
 	}
-	return fmt.Sprintf("public/%s/season_%d_week_%d.png", image, seasonID, week)
+	return fmt.Sprintf("public/%s/season_%d_week_%d%s.png", image, seasonID, week, suffix)
+}
+
+func sanitize(value string) string {
+	return strings.NewReplacer(" ", "_", "/", "_").Replace(strings.ToLower(value))
+}
+
+// Timeslot holds the results of all sessions in a timeslot, averaged over all raceweeks with sessions in it
+type Timeslot struct {
+	Official        bool    // was any of the sessions official
+	SizeOfField     int     // total number of drivers over all splits
+	StrengthOfField int     // SOF of the top split
+	Splits          float64 // number of splits
+	OfficialRate    int     // percentage of official sessions
+	RaceWeeks       int     // number of raceweeks with sessions in this timeslot
 }
 
-func GetResult(slot time.Time, results []database.RaceWeekResult) database.RaceWeekResult {
-	sessions := make([]database.RaceWeekResult, 0)
+func GetTimeslot(slot time.Time, results []database.RaceWeekResult) Timeslot {
+	// collect all splits of that timeslot, per raceweek
+	raceweeks := make(map[int][]database.RaceWeekResult)
 	for _, result := range results {
 		if result.StartTime.UTC().Weekday() == slot.UTC().Weekday() &&
 			result.StartTime.UTC().Hour() == slot.UTC().Hour() &&
 			result.StartTime.UTC().Minute() == slot.UTC().Minute() {
-			sessions = append(sessions, result)
+			raceweeks[result.RaceWeekID] = append(raceweeks[result.RaceWeekID], result)
 		}
 	}
 
-	// summarize splits
-	result := database.RaceWeekResult{
-		SizeOfField:     0,
-		StrengthOfField: 0,
+	// summarize splits of each raceweek, then average over all raceweeks
+	timeslot := Timeslot{RaceWeeks: len(raceweeks)}
+	if timeslot.RaceWeeks == 0 {
+		return timeslot
 	}
-	for _, session := range sessions {
-		result.Official = session.Official
-		result.SizeOfField += session.SizeOfField
-		if session.StrengthOfField > result.StrengthOfField {
-			result.StrengthOfField = session.StrengthOfField
+	var officials int
+	for _, splits := range raceweeks {
+		var sof int
+		var official bool
+		for _, split := range splits {
+			timeslot.SizeOfField += split.SizeOfField
+			if split.StrengthOfField > sof {
+				sof = split.StrengthOfField
+			}
+			if split.Official {
+				official = true
+			}
+		}
+		timeslot.StrengthOfField += sof
+		timeslot.Splits += float64(len(splits))
+		if official {
+			timeslot.Official = true
+			officials++
 		}
 	}
-	return result
+	timeslot.SizeOfField = timeslot.SizeOfField / timeslot.RaceWeeks
+	timeslot.StrengthOfField = timeslot.StrengthOfField / timeslot.RaceWeeks
+	timeslot.Splits = timeslot.Splits / float64(timeslot.RaceWeeks)
+	timeslot.OfficialRate = officials * 100 / timeslot.RaceWeeks
+	return timeslot
 }
 
 func MapValueIntoRange(rangeStart, rangeEnd, min, max, value int) int {
@@ -97,3 +133,68 @@ func MapValueIntoRange(rangeStart, rangeEnd, min, max, value int) int {
 	rangeSize := rangeEnd - rangeStart
 	return rangeStart + int((float64(value-min)/float64(max-min))*float64(rangeSize))
 }
+
+// Split holds the result of a single split of a timeslot, averaged over all raceweeks with sessions in it
+type Split struct {
+	Official        bool // was the split official in any of the raceweeks
+	SizeOfField     int
+	StrengthOfField int
+}
+
+func GetSplits(slot time.Time, results []database.RaceWeekResult) []Split {
+	// collect all splits of that timeslot, per raceweek and session
+	type sessionKey struct{ raceweek, id int }
+	sessions := make(map[sessionKey]map[int]database.RaceWeekResult)
+	for _, result := range results {
+		if result.StartTime.UTC().Weekday() == slot.UTC().Weekday() &&
+			result.StartTime.UTC().Hour() == slot.UTC().Hour() &&
+			result.StartTime.UTC().Minute() == slot.UTC().Minute() {
+			key := sessionKey{result.RaceWeekID, result.SessionID}
+			if _, ok := sessions[key]; !ok {
+				sessions[key] = make(map[int]database.RaceWeekResult)
+			}
+			sessions[key][result.SubsessionID] = result
+		}
+	}
+	if len(sessions) == 0 {
+		return []Split{}
+	}
+
+	// sort splits of each session by SOF, then average the n-th split over all sessions
+	var maxSplits int
+	sorted := make([][]database.RaceWeekResult, 0, len(sessions))
+	for _, subsessions := range sessions {
+		splits := make([]database.RaceWeekResult, 0, len(subsessions))
+		for _, split := range subsessions {
+			splits = append(splits, split)
+		}
+		sort.Slice(splits, func(i, j int) bool {
+			if splits[i].StrengthOfField == splits[j].StrengthOfField {
+				return splits[i].SubsessionID < splits[j].SubsessionID
+			}
+			return splits[i].StrengthOfField > splits[j].StrengthOfField
+		})
+		if len(splits) > maxSplits {
+			maxSplits = len(splits)
+		}
+		sorted = append(sorted, splits)
+	}
+	splits := make([]Split, maxSplits)
+	for n := range splits {
+		var count int
+		for _, session := range sorted {
+			if n >= len(session) {
+				continue
+			}
+			count++
+			splits[n].SizeOfField += session[n].SizeOfField
+			splits[n].StrengthOfField += session[n].StrengthOfField
+			if session[n].Official {
+				splits[n].Official = true
+			}
+		}
+		splits[n].SizeOfField = splits[n].SizeOfField / count
+		splits[n].StrengthOfField = splits[n].StrengthOfField / count
+	}
+	return splits
+}
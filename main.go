@@ -2,8 +2,10 @@ package main
 
 import (
 	"net/http"
+	_ "time/tzdata" // embed timezone database, for heatmaps in local time
 
 	"github.com/JamesClonk/iRvisualizer/env"
+	"github.com/JamesClonk/iRvisualizer/image"
 	"github.com/JamesClonk/iRvisualizer/log"
 	"github.com/JamesClonk/iRvisualizer/web"
 )
@@ -13,10 +15,27 @@ func main() {
 	level := env.Get("LOG_LEVEL", "info")
 	username := env.MustGet("AUTH_USERNAME")
 	password := env.MustGet("AUTH_PASSWORD")
+	image.AssetDir = env.Get("ASSET_DIR", image.AssetDir)
+	topPresets := env.Get("TOP_PRESETS", "")
+	eligibility := env.Get("ELIGIBILITY", "")
+	tieBreakers := env.Get("TIE_BREAKERS", "")
 
 	log.Infoln("port:", port)
 	log.Infoln("log level:", level)
 	log.Infoln("auth username:", username)
+	log.Infoln("asset directory:", image.AssetDir)
+	log.Infoln("top presets:", topPresets)
+	if err := web.AddTopPresets(topPresets); err != nil {
+		log.Fatalln(err)
+	}
+	log.Infoln("eligibility:", eligibility)
+	if err := web.AddEligibility(eligibility); err != nil {
+		log.Fatalln(err)
+	}
+	log.Infoln("tie-breakers:", tieBreakers)
+	if err := web.SetTieBreakers(tieBreakers); err != nil {
+		log.Fatalln(err)
+	}
 
 	// start listener
 	log.Fatalln(http.ListenAndServe(":"+port, web.NewRouter(username, password)))
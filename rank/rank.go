@@ -0,0 +1,128 @@
+package rank
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TieBreaker decides the order of two entries with an equal value, if possible
+type TieBreaker string
+
+const (
+	// Wins ranks the entry with more wins first
+	Wins TieBreaker = "wins"
+	// Recent ranks the entry with the better most recent result first, going back one result at a time until they differ
+	Recent TieBreaker = "recent"
+	// Incidents ranks the entry with fewer incidents first
+	Incidents TieBreaker = "incidents"
+)
+
+var tieBreakers = []TieBreaker{Wins, Recent, Incidents}
+
+// Entry is a single entry of a ranked list, with its value and all statistics needed for tie-breaking
+type Entry struct {
+	Name      string    // only used to keep the order of tied entries stable, never breaks a tie
+	Value     float64   // the value to rank by
+	Wins      int       // number of wins
+	Results   []float64 // results of the entry, the most recent first, higher is better
+	Incidents float64   // incidents, like per lap, fewer are better
+}
+
+// compare returns a negative number if entry a ranks before b, a positive number if after and 0 if they are tied
+func compare(a, b Entry, ascending bool, breakers []TieBreaker) int {
+	if a.Value != b.Value {
+		if (a.Value < b.Value) == ascending {
+			return -1
+		}
+		return 1
+	}
+	for _, breaker := range breakers {
+		switch breaker {
+		case Wins:
+			if a.Wins != b.Wins {
+				return b.Wins - a.Wins
+			}
+		case Recent:
+			for r := 0; r < len(a.Results) || r < len(b.Results); r++ {
+				var resultA, resultB float64
+				if r < len(a.Results) {
+					resultA = a.Results[r]
+				}
+				if r < len(b.Results) {
+					resultB = b.Results[r]
+				}
+				if resultA > resultB {
+					return -1
+				}
+				if resultA < resultB {
+					return 1
+				}
+			}
+		case Incidents:
+			if a.Incidents < b.Incidents {
+				return -1
+			}
+			if a.Incidents > b.Incidents {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// Order ranks all entries, highest value first unless ascending, with ties broken by the given tie-breakers in order.
+// It returns the indices of the entries in ranked order, and their standard competition positions ("1, 2, 2, 4")
+func Order(entries []Entry, ascending bool, breakers []TieBreaker) ([]int, []int) {
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := entries[order[i]], entries[order[j]]
+		if c := compare(a, b, ascending, breakers); c != 0 {
+			return c < 0
+		}
+		return a.Name < b.Name
+	})
+
+	positions := make([]int, len(order))
+	for i := range order {
+		positions[i] = i + 1
+		if i > 0 && compare(entries[order[i-1]], entries[order[i]], ascending, breakers) == 0 {
+			positions[i] = positions[i-1] // tied, share the position
+		}
+	}
+	return order, positions
+}
+
+// Parse parses a comma separated list of tie-breakers, like "wins,incidents"
+func Parse(value string) ([]TieBreaker, error) {
+	breakers := make([]TieBreaker, 0)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		valid := false
+		for _, breaker := range tieBreakers {
+			if TieBreaker(name) == breaker {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid tie-breaker [%s], must be one of %s", name, strings.Join(Names(), ", "))
+		}
+		breakers = append(breakers, TieBreaker(name))
+	}
+	return breakers, nil
+}
+
+// Names returns the names of all tie-breakers
+func Names() []string {
+	names := make([]string, 0, len(tieBreakers))
+	for _, breaker := range tieBreakers {
+		names = append(names, string(breaker))
+	}
+	return names
+}
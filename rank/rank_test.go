@@ -0,0 +1,55 @@
+package rank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Rank_Order(t *testing.T) {
+	entries := []Entry{
+		{Name: "D", Value: 80, Wins: 1, Results: []float64{20, 50}, Incidents: 0.1},
+		{Name: "A", Value: 100, Wins: 0, Results: []float64{10}, Incidents: 0.2},
+		{Name: "C", Value: 80, Wins: 2, Results: []float64{30, 10}, Incidents: 0.3},
+		{Name: "B", Value: 80, Wins: 1, Results: []float64{20, 40}, Incidents: 0.1},
+		{Name: "E", Value: 50},
+	}
+
+	// standard competition ranking without tie-breakers, ties ordered by name
+	order, positions := Order(entries, false, nil)
+	assert.Equal(t, []int{1, 3, 2, 0, 4}, order)
+	assert.Equal(t, []int{1, 2, 2, 2, 5}, positions)
+
+	order, positions = Order(entries, false, []TieBreaker{Wins})
+	assert.Equal(t, []int{1, 2, 3, 0, 4}, order)
+	assert.Equal(t, []int{1, 2, 3, 3, 5}, positions)
+
+	order, positions = Order(entries, false, []TieBreaker{Wins, Recent})
+	assert.Equal(t, []int{1, 2, 0, 3, 4}, order)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, positions)
+
+	order, positions = Order(entries, false, []TieBreaker{Incidents})
+	assert.Equal(t, []int{1, 3, 0, 2, 4}, order)
+	assert.Equal(t, []int{1, 2, 2, 4, 5}, positions)
+
+	// lowest value first
+	order, positions = Order(entries, true, nil)
+	assert.Equal(t, []int{4, 3, 2, 0, 1}, order)
+	assert.Equal(t, []int{1, 2, 2, 2, 5}, positions)
+
+	_, positions = Order(nil, false, nil)
+	assert.Empty(t, positions)
+}
+
+func Test_Rank_Parse(t *testing.T) {
+	breakers, err := Parse("Wins, incidents")
+	assert.NoError(t, err)
+	assert.Equal(t, []TieBreaker{Wins, Incidents}, breakers)
+
+	breakers, err = Parse("")
+	assert.NoError(t, err)
+	assert.Empty(t, breakers)
+
+	_, err = Parse("wins,luck")
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Rules define how the championship points of a season are calculated out of all race results
+type Rules interface {
+	// Name returns the name of the rules preset
+	Name() string
+	// WeeklyPoints returns the points of a driver for a single week, out of the championship points of all their races of that week
+	WeeklyPoints(races []int) float64
+	// CountedWeeks returns how many weeks count towards the championship, out of all weeks so far
+	CountedWeeks(weeks int) int
+	// Bonus returns the additional points of a driver, for the number of weeks they participated in
+	Bonus(participations int) float64
+}
+
+// Preset is a set of scoring rules, made up of a weekly points calculation, dropweeks and bonus points
+type Preset struct {
+	name        string
+	races       func(races []int) float64
+	dropWeeks   func(weeks int) int
+	weeklyBonus float64
+}
+
+func (p Preset) Name() string {
+	return p.name
+}
+
+func (p Preset) WeeklyPoints(races []int) float64 {
+	if len(races) == 0 {
+		return 0
+	}
+	values := make([]int, len(races))
+	copy(values, races)
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] > values[j]
+	})
+	return p.races(values)
+}
+
+func (p Preset) CountedWeeks(weeks int) int {
+	counted := weeks - p.dropWeeks(weeks)
+	if counted < 1 && weeks > 0 {
+		counted = 1
+	}
+	return counted
+}
+
+func (p Preset) Bonus(participations int) float64 {
+	return p.weeklyBonus * float64(participations)
+}
+
+// TopQuarterAverage is the average of the top quarter of all races of a week, like on iRacing
+func TopQuarterAverage(races []int) float64 {
+	count := int(math.Ceil(float64(len(races)) / 4))
+	var result float64
+	for i := 0; i < count; i++ {
+		result += float64(races[i])
+	}
+	return result / float64(count)
+}
+
+// BestRace is the single best race of a week
+func BestRace(races []int) float64 {
+	return float64(races[0])
+}
+
+// DropThird drops a third of all weeks, like on iRacing
+func DropThird(weeks int) int {
+	return int(math.Floor(float64(weeks) / 3))
+}
+
+// DropThirdAfter drops a third of all weeks, but only once there are more than n weeks
+func DropThirdAfter(n int) func(weeks int) int {
+	return func(weeks int) int {
+		if weeks <= n {
+			return 0
+		}
+		return DropThird(weeks)
+	}
+}
+
+// DropFixed always drops n weeks
+func DropFixed(n int) func(weeks int) int {
+	return func(weeks int) int {
+		return n
+	}
+}
+
+var presets = map[string]Preset{
+	"iracing":   {name: "iracing", races: TopQuarterAverage, dropWeeks: DropThird},
+	"few_weeks": {name: "few_weeks", races: TopQuarterAverage, dropWeeks: DropThirdAfter(3)}, // no dropweeks below 4 weeks
+	"best":      {name: "best", races: BestRace, dropWeeks: DropThird},
+	"drop2":     {name: "drop2", races: TopQuarterAverage, dropWeeks: DropFixed(2)},
+	"league":    {name: "league", races: BestRace, dropWeeks: DropFixed(2), weeklyBonus: 10},
+}
+
+// IRacing returns the official iRacing championship rules
+func IRacing() Rules {
+	return presets["iracing"]
+}
+
+// Get returns the rules preset with the given name, or the iRacing rules if no name is given
+func Get(name string) (Rules, error) {
+	if len(name) == 0 {
+		return IRacing(), nil
+	}
+	if preset, ok := presets[strings.ToLower(name)]; ok {
+		return preset, nil
+	}
+	return nil, fmt.Errorf("invalid rules [%s], must be one of %s", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names of all rules presets
+func Names() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
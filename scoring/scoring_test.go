@@ -0,0 +1,38 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Scoring_Presets(t *testing.T) {
+	races := []int{50, 80, 70, 60, 90}
+
+	rules, err := Get("")
+	assert.NoError(t, err)
+	assert.Equal(t, "iracing", rules.Name())
+	assert.Equal(t, 85.0, rules.WeeklyPoints(races)) // top 2 out of 5
+	assert.Equal(t, 0.0, rules.WeeklyPoints(nil))
+	assert.Equal(t, 8, rules.CountedWeeks(12))
+	assert.Equal(t, 1, rules.CountedWeeks(1))
+	assert.Equal(t, 0.0, rules.Bonus(12))
+
+	// input must not be reordered
+	assert.Equal(t, []int{50, 80, 70, 60, 90}, races)
+
+	rules, err = Get("few_weeks")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, rules.CountedWeeks(3))
+	assert.Equal(t, 3, rules.CountedWeeks(4))
+
+	rules, err = Get("League")
+	assert.NoError(t, err)
+	assert.Equal(t, 90.0, rules.WeeklyPoints(races))
+	assert.Equal(t, 10, rules.CountedWeeks(12))
+	assert.Equal(t, 1, rules.CountedWeeks(2))
+	assert.Equal(t, 30.0, rules.Bonus(3))
+
+	_, err = Get("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// SessionTimes returns the start times of all sessions of a timeslots crontab schedule within [from, to)
+func SessionTimes(timeslots string, from, to time.Time) ([]time.Time, error) {
+	p := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	schedule, err := p.Parse(timeslots)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse timeslot [%s] to crontab format: %v", timeslots, err)
+	}
+
+	// start -1 minute earlier, to make sure schedule.Next will catch a session starting exactly at from
+	sessions := make([]time.Time, 0)
+	for next := schedule.Next(from.Add(-1 * time.Minute)); next.Before(to); next = schedule.Next(next) {
+		sessions = append(sessions, next)
+	}
+	return sessions, nil
+}
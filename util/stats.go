@@ -0,0 +1,53 @@
+package util
+
+import (
+	"math"
+	"sort"
+)
+
+// Quantile returns the q-th quantile (0.0 - 1.0) of the given values, linearly interpolated between the closest ranks
+func Quantile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	if q <= 0 {
+		return sorted[0]
+	}
+	if q >= 1 {
+		return sorted[len(sorted)-1]
+	}
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// Median returns the median of the given values
+func Median(values []float64) float64 {
+	return Quantile(values, 0.5)
+}
+
+// LinearRegression returns slope and intercept of the least squares line through the given points
+func LinearRegression(xs, ys []float64) (float64, float64) {
+	n := math.Min(float64(len(xs)), float64(len(ys)))
+	if n == 0 {
+		return 0, 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i := 0; i < int(n); i++ {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, sumY / n // all x values are the same, no slope to be found
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	return slope, (sumY - slope*sumX) / n
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Util_Quantile(t *testing.T) {
+	values := []float64{7, 1, 3, 5, 9}
+	assert.Equal(t, 1.0, Quantile(values, 0))
+	assert.Equal(t, 3.0, Quantile(values, 0.25))
+	assert.Equal(t, 5.0, Median(values))
+	assert.Equal(t, 7.0, Quantile(values, 0.75))
+	assert.Equal(t, 9.0, Quantile(values, 1))
+	assert.Equal(t, 2.0, Quantile([]float64{1, 3}, 0.5))
+	assert.Equal(t, 0.0, Median(nil))
+
+	// input must not be reordered
+	assert.Equal(t, []float64{7, 1, 3, 5, 9}, values)
+}
+
+func Test_Util_LinearRegression(t *testing.T) {
+	slope, intercept := LinearRegression([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9})
+	assert.InDelta(t, 2.0, slope, 0.0001)
+	assert.InDelta(t, 1.0, intercept, 0.0001)
+
+	slope, intercept = LinearRegression([]float64{2, 2}, []float64{1, 3})
+	assert.Equal(t, 0.0, slope)
+	assert.Equal(t, 2.0, intercept)
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/util"
+	"github.com/JamesClonk/iRvisualizer/web/ical"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) seasonCalendar(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("calendar: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	// was there a timezone given? days and hours are filtered in local time
+	location, err := getLocation(req)
+	if err != nil {
+		log.Errorf("calendar: could not load timezone [%s]: %v", req.URL.Query().Get("tz"), err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// were there any days given?
+	days, err := parseDays(req.URL.Query().Get("days"))
+	if err != nil {
+		log.Errorf("calendar: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// were there any hours given?
+	hours, err := parseHours(req.URL.Query().Get("hours"))
+	if err != nil {
+		log.Errorf("calendar: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// was there a race duration given?
+	duration := 60
+	value := req.URL.Query().Get("duration")
+	if len(value) > 0 {
+		duration, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("calendar: could not convert duration [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if duration < 1 || duration > 24*60 {
+		duration = 60
+	}
+
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("calendar: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// collect all upcoming sessions of each raceweek
+	events := make([]ical.Event, 0)
+	for week := 0; week < 13; week++ {
+		_, track, err := h.getRaceWeek(seasonID, week)
+		if err != nil {
+			log.Debugf("calendar: could not get raceweek for season[%d], week[%d]: %v", seasonID, week, err)
+			continue
+		}
+		name := scheduleTrack(track)
+		category := scheduleCategory(track)
+
+		weekStart, weekEnd := raceWeekBounds(season, week)
+		sessions, err := util.SessionTimes(season.Timeslots, weekStart, weekEnd)
+		if err != nil {
+			log.Errorf("calendar: %v", err)
+			h.failure(rw, req, err)
+			return
+		}
+		for _, session := range sessions {
+			local := session.In(location)
+			if session.Before(time.Now().Add(-2*time.Hour)) || (len(days) > 0 && !days[local.Weekday()]) || (len(hours) > 0 && !hours[local.Hour()]) {
+				continue
+			}
+			events = append(events, ical.Event{
+				UID:         fmt.Sprintf("%d-%d@irvisualizer", seasonID, session.Unix()),
+				Start:       session,
+				End:         session.Add(time.Duration(duration) * time.Minute),
+				Summary:     fmt.Sprintf("%s - %s", season.SeasonNameShort, track.Name),
+				Description: fmt.Sprintf("%s\nWeek %d: %s (%s)", season.SeasonName, week+1, name, category),
+				Location:    name,
+			})
+		}
+	}
+
+	rw.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, ical.Filename(season.SeasonNameShort)))
+	rw.WriteHeader(200)
+	_, _ = rw.Write(ical.Calendar(season.SeasonName, events))
+}
+
+// parseDays parses a list of weekdays, like "sat,sun" or "saturday,sunday"
+func parseDays(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	if len(value) == 0 {
+		return days, nil
+	}
+	for _, day := range strings.Split(strings.ToLower(value), ",") {
+		day = strings.TrimSpace(day)
+		found := false
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if len(day) >= 3 && strings.HasPrefix(strings.ToLower(weekday.String()), day) {
+				days[weekday] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid day [%s]", day)
+		}
+	}
+	return days, nil
+}
+
+// parseHours parses a list of hours or hour ranges, like "18,20" or "18-22"
+func parseHours(value string) (map[int]bool, error) {
+	hours := make(map[int]bool)
+	if len(value) == 0 {
+		return hours, nil
+	}
+	for _, hour := range strings.Split(value, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(hour), "-", 2)
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not convert hour [%s] to int: %v", hour, err)
+		}
+		to := from
+		if len(bounds) == 2 {
+			to, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("could not convert hour [%s] to int: %v", hour, err)
+			}
+		}
+		if from < 0 || from > 23 || to < 0 || to > 23 {
+			return nil, fmt.Errorf("invalid hour [%s], must be between 0 and 23", hour)
+		}
+		for h := from; ; h = (h + 1) % 24 { // ranges can wrap around midnight, like "22-2"
+			hours[h] = true
+			if h == to {
+				break
+			}
+		}
+	}
+	return hours, nil
+}
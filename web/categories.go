@@ -0,0 +1,96 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/log"
+)
+
+// trackCategories are all track categories that can be filtered for
+var trackCategories = []string{"road", "oval", "dirt_road", "dirt_oval"}
+
+// getCategory reads the optional "category" query parameter, a track category, and returns its image file variants.
+// There are no variants if no category is given
+func getCategory(req *http.Request) (string, []string, error) {
+	value := strings.ToLower(req.URL.Query().Get("category"))
+	if len(value) == 0 {
+		return "", []string{}, nil
+	}
+	for _, category := range trackCategories {
+		if value == category {
+			return category, []string{"category_" + category}, nil
+		}
+	}
+	return "", nil, fmt.Errorf("invalid category [%s], must be one of %s", value, strings.Join(trackCategories, ", "))
+}
+
+// withCategory returns a copy of a request with the given track category, unless it already has one
+func withCategory(req *http.Request, category string) *http.Request {
+	return withDefault(req, "category", category)
+}
+
+// withDefault returns a copy of a request with a query parameter set, unless it was already given
+func withDefault(req *http.Request, param, value string) *http.Request {
+	query := req.URL.Query()
+	if len(query.Get(param)) > 0 {
+		return req
+	}
+	query.Set(param, value)
+	defaultReq := req.Clone(req.Context())
+	defaultReq.URL.RawQuery = query.Encode()
+	return defaultReq
+}
+
+// trackCategory returns the category of a track, either road, oval, dirt_road or dirt_oval
+func trackCategory(track database.Track) string {
+	category := "road"
+	if track.IsOval || strings.Contains(strings.ToLower(track.Category), "oval") {
+		category = "oval"
+	}
+	if track.IsDirt || strings.HasPrefix(strings.ToLower(track.Category), "dirt") {
+		category = "dirt_" + category
+	}
+	return category
+}
+
+// categoryName returns a readable name of a track category, like "Dirt Oval"
+func categoryName(category string) string {
+	words := strings.Split(category, "_")
+	for w, word := range words {
+		if len(word) > 0 {
+			words[w] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// hasCategory checks if a track belongs to a track category, always true for no category
+func hasCategory(category string, track database.Track) bool {
+	if len(category) == 0 {
+		return true
+	}
+	return trackCategory(track) == category
+}
+
+// isCategoryWeek checks if the track of a raceweek belongs to a track category, always true for no category.
+// A raceweek that doesn't exist yet hasn't started, it belongs to no category
+func (h *Handler) isCategoryWeek(seasonID, week int, category string) (bool, error) {
+	if len(category) == 0 {
+		return true, nil
+	}
+	_, track, err := h.getRaceWeek(seasonID, week)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Debugf("could not get raceweek for season[%d], week[%d]: %v", seasonID, week, err)
+		return false, nil // raceweek hasn't started yet
+	}
+	if err != nil {
+		log.Errorf("could not get raceweek for season[%d], week[%d]: %v", seasonID, week, err)
+		return false, err
+	}
+	return hasCategory(category, track), nil
+}
@@ -0,0 +1,253 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/log"
+)
+
+// carClassAll selects all car classes, with a separate section for each of them
+const carClassAll = -1
+
+// carClasses holds the car classes of a raceweek or season, and which class each driver raced in
+type carClasses struct {
+	IDs         []int
+	Cars        map[int][]database.Car // cars of each car class
+	Subsessions map[int]map[int]int    // car class by subsessionID and driverID
+	Drivers     map[int]int            // car class by driverID, the one a driver raced most in
+}
+
+// getCarClass reads the optional "carClass" query parameter, either a car class ID or "all"
+func getCarClass(req *http.Request) (int, error) {
+	value := req.URL.Query().Get("carClass")
+	if len(value) == 0 {
+		return 0, nil
+	}
+	if strings.ToLower(value) == "all" {
+		return carClassAll, nil
+	}
+	carClass, err := strconv.Atoi(value)
+	if err != nil {
+		log.Errorf("could not convert carClass [%s] to int: %v", value, err)
+		return 0, err
+	}
+	if carClass < 0 {
+		return 0, fmt.Errorf("invalid carClass [%s]", value)
+	}
+	return carClass, nil
+}
+
+// carClassVariants returns the image file variants for a car class selection, none if there is none
+func carClassVariants(carClass int) []string {
+	switch {
+	case carClass == carClassAll:
+		return []string{"classes"}
+	case carClass > 0:
+		return []string{fmt.Sprintf("class_%d", carClass)}
+	}
+	return []string{}
+}
+
+func newCarClasses() carClasses {
+	return carClasses{
+		IDs:         make([]int, 0),
+		Cars:        make(map[int][]database.Car),
+		Subsessions: make(map[int]map[int]int),
+		Drivers:     make(map[int]int),
+	}
+}
+
+func (h *Handler) getCarClasses(seasonID, week int) (carClasses, error) {
+	log.Infof("collect car classes for season [%d], week [%d]", seasonID, week)
+
+	classes := newCarClasses()
+	raceweek, err := h.DB.GetRaceWeekBySeasonIDAndWeek(seasonID, week)
+	if err != nil {
+		log.Debugf("could not get raceweek for season[%d], week[%d]: %v", seasonID, week, err)
+		return classes, nil // raceweek hasn't started yet, no classes
+	}
+	classes.IDs, err = h.DB.GetCarClassIDsByRaceWeekID(raceweek.RaceWeekID)
+	if err != nil {
+		return classes, err
+	}
+	results, err := h.DB.GetRaceResultsBySeasonIDAndWeek(seasonID, week)
+	if err != nil {
+		return classes, err
+	}
+	ttResults, err := h.DB.GetTimeTrialResultsBySeasonIDAndWeek(seasonID, week)
+	if err != nil {
+		return classes, err
+	}
+
+	// figure out car class for each subsession and driver
+	carClassByCar := make(map[int]int)
+	races := make(map[int]map[int]int) // number of races by driverID and car class
+	for _, result := range results {
+		carClassByCar[result.CarID] = result.CarClassID
+		if _, ok := classes.Subsessions[result.SubsessionID]; !ok {
+			classes.Subsessions[result.SubsessionID] = make(map[int]int)
+		}
+		classes.Subsessions[result.SubsessionID][result.Driver.DriverID] = result.CarClassID
+		if _, ok := races[result.Driver.DriverID]; !ok {
+			races[result.Driver.DriverID] = make(map[int]int)
+		}
+		races[result.Driver.DriverID][result.CarClassID]++
+	}
+	for driverID, counts := range races {
+		var most int
+		for _, carClass := range classes.IDs {
+			if counts[carClass] > most {
+				most = counts[carClass]
+				classes.Drivers[driverID] = carClass
+			}
+		}
+	}
+	// time trial only drivers
+	for _, tt := range ttResults {
+		if _, ok := classes.Drivers[tt.Driver.DriverID]; !ok {
+			classes.Drivers[tt.Driver.DriverID] = tt.CarClassID
+		}
+	}
+
+	// collect cars for class names
+	cars, err := h.DB.GetCarsByRaceWeekID(raceweek.RaceWeekID)
+	if err != nil {
+		return classes, err
+	}
+	for _, car := range cars {
+		if carClass, ok := carClassByCar[car.CarID]; ok {
+			classes.Cars[carClass] = append(classes.Cars[carClass], car)
+		}
+	}
+	return classes, nil
+}
+
+// getWeeklyCarClasses collects the car classes of all weeks of a season, indexed by week
+func (h *Handler) getWeeklyCarClasses(seasonID int) ([]carClasses, error) {
+	weekly := make([]carClasses, 0)
+	for week := 0; week < 13; week++ { // allow for leap seasons with 13 official weeks
+		classes, err := h.getCarClasses(seasonID, week)
+		if err != nil {
+			return nil, err
+		}
+		weekly = append(weekly, classes)
+	}
+	return weekly, nil
+}
+
+// seasonCarClasses merges the car classes of all weeks of a season
+func seasonCarClasses(weekly []carClasses) carClasses {
+	classes := newCarClasses()
+	for _, weeklyClasses := range weekly {
+		classes.merge(weeklyClasses)
+	}
+	return classes
+}
+
+// merge adds all car classes and cars of another raceweek, drivers and subsessions are only kept per raceweek
+func (c *carClasses) merge(other carClasses) {
+	for _, carClass := range other.IDs {
+		if !c.has(carClass) {
+			c.IDs = append(c.IDs, carClass)
+		}
+		for _, car := range other.Cars[carClass] {
+			known := false
+			for _, existing := range c.Cars[carClass] {
+				if existing.CarID == car.CarID {
+					known = true
+				}
+			}
+			if !known {
+				c.Cars[carClass] = append(c.Cars[carClass], car)
+			}
+		}
+	}
+	sort.Ints(c.IDs)
+}
+
+func (c carClasses) has(carClass int) bool {
+	for _, id := range c.IDs {
+		if id == carClass {
+			return true
+		}
+	}
+	return false
+}
+
+// selection returns the car classes to draw for a carClass query parameter, 0 for all classes combined
+func (c carClasses) selection(carClass int) []int {
+	if carClass == carClassAll {
+		if len(c.IDs) == 0 {
+			return []int{0} // no classes known yet
+		}
+		return c.IDs
+	}
+	return []int{carClass}
+}
+
+// name returns a readable name of a car class, made up of its cars
+func (c carClasses) name(carClass int) string {
+	names := make([]string, 0)
+	for _, car := range c.Cars[carClass] {
+		name := car.Abbreviation
+		if len(name) == 0 {
+			name = car.Name
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("Class %d", carClass)
+	}
+	if len(names) > 3 {
+		names = append(names[:3], "...")
+	}
+	return strings.Join(names, ", ")
+}
+
+// hasDriver checks if a driver raced in a car class, always true for 0 / no car class
+func (c carClasses) hasDriver(carClass, driverID int) bool {
+	if carClass == 0 {
+		return true
+	}
+	return c.Drivers[driverID] == carClass
+}
+
+// hasResult checks if a subsession result of a driver belongs to a car class, always true for 0 / no car class
+func (c carClasses) hasResult(carClass, subsessionID, driverID int) bool {
+	if carClass == 0 {
+		return true
+	}
+	if drivers, ok := c.Subsessions[subsessionID]; ok {
+		if class, ok := drivers[driverID]; ok {
+			return class == carClass
+		}
+	}
+	return c.hasDriver(carClass, driverID)
+}
+
+// summaries returns only the summaries of drivers in a car class
+func (c carClasses) summaries(carClass int, summaries []database.Summary) []database.Summary {
+	filtered := make([]database.Summary, 0)
+	for _, summary := range summaries {
+		if c.hasDriver(carClass, summary.Driver.DriverID) {
+			filtered = append(filtered, summary)
+		}
+	}
+	return filtered
+}
+
+// laptimes returns only the laptimes of drivers in a car class
+func (c carClasses) laptimes(carClass int, laptimes []database.FastestLaptime) []database.FastestLaptime {
+	filtered := make([]database.FastestLaptime, 0)
+	for _, laptime := range laptimes {
+		if c.hasDriver(carClass, laptime.Driver.DriverID) {
+			filtered = append(filtered, laptime)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,317 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image/clubs"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/gorilla/mux"
+)
+
+var clubsMutex = &sync.Mutex{}
+
+// clubStanding holds the club points of a club and the points each of its drivers contributed to it
+type clubStanding struct {
+	Club    database.Club
+	Points  int
+	Drivers []standing
+}
+
+// clubStandings sums up the club points of all drivers per club
+func clubStandings(summaries []database.Summary) []clubStanding {
+	clubs := make(map[int]database.Club)
+	points := make(map[int]map[int]int) // clubID -> driverID -> points
+	drivers := make(map[int]database.Driver)
+	for _, summary := range summaries {
+		if summary.TotalClubPoints <= 0 {
+			continue
+		}
+		club := summary.Driver.Club
+		if _, ok := points[club.ClubID]; !ok {
+			points[club.ClubID] = make(map[int]int)
+		}
+		clubs[club.ClubID] = club
+		points[club.ClubID][summary.Driver.DriverID] += summary.TotalClubPoints
+		drivers[summary.Driver.DriverID] = summary.Driver
+	}
+
+	standings := make([]clubStanding, 0)
+	for clubID, club := range clubs {
+		cs := clubStanding{
+			Club:    club,
+			Drivers: make([]standing, 0),
+		}
+		for driverID, value := range points[clubID] {
+			cs.Points += value
+			cs.Drivers = append(cs.Drivers, standing{Driver: drivers[driverID], Points: value})
+		}
+		sortStandings(cs.Drivers)
+		standings = append(standings, cs)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points == standings[j].Points {
+			return standings[i].Club.Name < standings[j].Club.Name
+		}
+		return standings[i].Points > standings[j].Points
+	})
+	return standings
+}
+
+// getClubStandings returns the club standings of a single week, or of the whole season if week is <= 0
+func (h *Handler) getClubStandings(seasonID, week int) ([]clubStanding, error) {
+	if week <= 0 {
+		summaries, err := h.getSeasonSummaries(seasonID)
+		if err != nil {
+			log.Errorf("clubs: could not get season summaries for season[%d]: %v", seasonID, err)
+			return nil, err
+		}
+		return clubStandings(summaries), nil
+	}
+
+	summaries, err := h.getRaceWeekSummaries(seasonID, week-1)
+	if err != nil {
+		log.Errorf("clubs: could not get raceweek summaries for season[%d], week[%d]: %v", seasonID, week-1, err)
+		return nil, err
+	}
+	return clubStandings(summaries), nil
+}
+
+// parseClubParameters reads seasonID, and optionally week, from the request path. week is -1 for the whole season
+func parseClubParameters(req *http.Request) (int, int, error) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("clubs: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		return 0, 0, err
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	week := -1
+	if len(vars["week"]) > 0 {
+		week, err = strconv.Atoi(vars["week"])
+		if err != nil {
+			log.Errorf("clubs: could not convert week [%s] to int: %v", vars["week"], err)
+			return 0, 0, err
+		}
+		if week < 1 || week > 13 {
+			week = 1
+		}
+	}
+	return seasonID, week, nil
+}
+
+func (h *Handler) clubRanking(rw http.ResponseWriter, req *http.Request) {
+	seasonID, week, err := parseClubParameters(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a topN given?
+	topN := 10
+	value := req.URL.Query().Get("topN")
+	if len(value) > 0 {
+		topN, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("clubs: could not convert topN [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if topN < 1 || topN > 25 {
+		topN = 10
+	}
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value = req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("clubs: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// is there a club given?
+	club := req.URL.Query().Get("club")
+
+	// were there any track or car details requested?
+	enrichment, err := getEnrichment(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants := enrichment.Variants()
+	if topN != 10 {
+		variants = append(variants, fmt.Sprintf("top%d", topN))
+	}
+	if len(club) > 0 {
+		variants = append(variants, "club_"+slug(club))
+	}
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && clubs.IsAvailable(colorScheme, seasonID, week, variants...) {
+		http.ServeFile(rw, req, clubs.Filename(seasonID, week, variants...))
+		return
+	}
+	// lock global mutex
+	clubsMutex.Lock()
+	defer clubsMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && clubs.IsAvailable(colorScheme, seasonID, week, variants...) {
+		http.ServeFile(rw, req, clubs.Filename(seasonID, week, variants...))
+		return
+	}
+
+	// create/update club ranking image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("clubs: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	raceweek := database.RaceWeek{RaceWeek: -1, LastUpdate: time.Now()}
+	track := database.Track{}
+	if week > 0 {
+		raceweek, track, err = h.getRaceWeek(seasonID, week-1)
+		if err != nil {
+			log.Debugf("clubs: could not get raceweek for season[%d], week[%d]: %v", seasonID, week-1, err)
+			raceweek.RaceWeek = week - 1
+			raceweek.LastUpdate = time.Now()
+			track.Name = "starting soon..."
+		}
+		if enrichment.CarLogos {
+			enrichment.Cars = h.getRaceWeekCars(raceweek.RaceWeekID)
+		}
+	}
+	standings, err := h.getClubStandings(seasonID, week)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	data := make([]clubs.DataRow, 0)
+	for c, cs := range standings {
+		if c >= topN {
+			break
+		}
+		row := clubs.DataRow{
+			Club:    cs.Club.Name,
+			Value:   fmt.Sprintf("%d", cs.Points),
+			Drivers: make([]clubs.DataDriver, 0),
+			Marked:  len(club) > 0 && (cs.Club.Name == club || strconv.Itoa(cs.Club.ClubID) == club),
+		}
+		for _, driver := range cs.Drivers {
+			row.Drivers = append(row.Drivers, clubs.DataDriver{
+				Driver: driver.Driver.Name,
+				Value:  fmt.Sprintf("%d", driver.Points),
+			})
+		}
+		data = append(data, row)
+	}
+
+	c := clubs.New(colorScheme, season, raceweek, track, data)
+	c.Variants = variants
+	c.Enrichment = enrichment
+	if err := c.Draw(); err != nil {
+		log.Errorf("clubs: could not create club ranking: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, clubs.Filename(seasonID, week, variants...))
+}
+
+type clubJson struct {
+	Position int              `json:"position"`
+	ClubID   int              `json:"club_id"`
+	Name     string           `json:"name"`
+	Points   int              `json:"points"`
+	Drivers  []clubDriverJson `json:"drivers"`
+}
+
+type clubDriverJson struct {
+	DriverID int    `json:"driver_id"`
+	Name     string `json:"name"`
+	Points   int    `json:"points"`
+}
+
+func (h *Handler) clubRankingJson(rw http.ResponseWriter, req *http.Request) {
+	seasonID, week, err := parseClubParameters(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	// how many contributing drivers should be listed per club?
+	topDrivers := 5
+	value := req.URL.Query().Get("topDrivers")
+	if len(value) > 0 {
+		topDrivers, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("clubs: could not convert topDrivers [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// is there a club given?
+	club := req.URL.Query().Get("club")
+
+	standings, err := h.getClubStandings(seasonID, week)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	result := make([]clubJson, 0)
+	for c, cs := range standings {
+		if len(club) > 0 && cs.Club.Name != club && strconv.Itoa(cs.Club.ClubID) != club {
+			continue
+		}
+		cj := clubJson{
+			Position: c + 1,
+			ClubID:   cs.Club.ClubID,
+			Name:     cs.Club.Name,
+			Points:   cs.Points,
+			Drivers:  make([]clubDriverJson, 0),
+		}
+		for d, driver := range cs.Drivers {
+			if topDrivers > 0 && d >= topDrivers {
+				break
+			}
+			cj.Drivers = append(cj.Drivers, clubDriverJson{
+				DriverID: driver.Driver.DriverID,
+				Name:     driver.Driver.Name,
+				Points:   driver.Points,
+			})
+		}
+		result = append(result, cj)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Errorf("clubs: could not marshal club standings: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(200)
+	_, _ = rw.Write(data)
+}
@@ -82,6 +82,20 @@ func (h *Handler) getRaceWeekSummaries(seasonID, week int) ([]database.Summary,
 	return summaries, nil
 }
 
+func (h *Handler) getSeasonSummaries(seasonID int) ([]database.Summary, error) {
+	log.Infof("collect season summaries for season [%d]", seasonID)
+
+	summaries := make([]database.Summary, 0)
+	for week := 0; week < 13; week++ { // allow for leap seasons with 13 official weeks
+		weeklySummaries, err := h.DB.GetDriverSummariesBySeasonIDAndWeek(seasonID, week)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, weeklySummaries...)
+	}
+	return summaries, nil
+}
+
 func (h *Handler) getRaceWeekSummariesByTeam(seasonID, week int, team string) ([]database.Summary, error) {
 	log.Infof("collect raceweek summaries for season [%d], week [%d], team [%s]", seasonID, week, team)
 
@@ -102,6 +116,27 @@ func (h *Handler) getSeasonSummariesByTeam(seasonID int, team string) ([]databas
 	return summaries, nil
 }
 
+func (h *Handler) getSeasonSummariesByTeamAndCategory(seasonID int, team, category string) ([]database.Summary, error) {
+	log.Infof("collect season summaries for season [%d], team [%s], category [%s]", seasonID, team, category)
+
+	summaries := make([]database.Summary, 0)
+	for week := 0; week < 13; week++ { // allow for leap seasons with 13 official weeks
+		isCategory, err := h.isCategoryWeek(seasonID, week, category)
+		if err != nil {
+			return nil, err
+		}
+		if !isCategory {
+			continue
+		}
+		weeklySummaries, err := h.DB.GetDriverSummariesBySeasonIDAndWeekAndTeam(seasonID, week, team)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, weeklySummaries...)
+	}
+	return mergeSummaries(summaries), nil
+}
+
 func (h *Handler) getRaceWeekTimeRankings(seasonID, week int) ([]database.TimeRanking, error) {
 	log.Infof("collect raceweek timerankings for season [%d], week [%d]", seasonID, week)
 
@@ -142,22 +177,34 @@ func (h *Handler) getChampPoints(seasonID, week int) ([]database.Points, error)
 	return points, nil
 }
 
-func (h *Handler) getChampPointsForOvals(seasonID, week int) ([]database.Points, error) {
-	log.Infof("collect championship points for oval tracks for season [%d], week [%d]", seasonID, week)
+func (h *Handler) getTTStandings(seasonID, week int) ([]database.TimeTrialResult, error) {
+	log.Infof("collect time trial results for season [%d], week [%d]", seasonID, week)
 
-	points, err := h.DB.GetPointsBySeasonIDAndWeekAndTrackCategory(seasonID, week, "oval")
+	results, err := h.DB.GetTimeTrialResultsBySeasonIDAndWeek(seasonID, week)
 	if err != nil {
 		return nil, err
 	}
-	return points, nil
+	return results, nil
 }
 
-func (h *Handler) getTTStandings(seasonID, week int) ([]database.TimeTrialResult, error) {
-	log.Infof("collect time trial results for season [%d], week [%d]", seasonID, week)
+func (h *Handler) getTTStandingsByCarClass(seasonID, week, carClass int) ([]database.TimeTrialResult, error) {
+	log.Infof("collect time trial results for season [%d], week [%d], car class [%d]", seasonID, week, carClass)
 
-	results, err := h.DB.GetTimeTrialResultsBySeasonIDAndWeek(seasonID, week)
+	results, err := h.DB.GetTimeTrialResultsBySeasonIDWeekAndCarClass(seasonID, week, carClass)
 	if err != nil {
 		return nil, err
 	}
 	return results, nil
 }
+
+func (h *Handler) getRaceWeekCars(raceweekID int) []database.Car {
+	log.Infof("collect cars for raceweek [%d]", raceweekID)
+
+	// cars are optional, for car logos only
+	cars, err := h.DB.GetCarsByRaceWeekID(raceweekID)
+	if err != nil {
+		log.Errorf("could not get cars for raceweek [%d]: %v", raceweekID, err)
+		return []database.Car{}
+	}
+	return cars
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JamesClonk/iRvisualizer/image/distribution"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/gorilla/mux"
+)
+
+var distributionMutex = &sync.Mutex{}
+
+func (h *Handler) weeklyLaptimeDistribution(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("distribution: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+	week, err := strconv.Atoi(vars["week"])
+	if err != nil {
+		log.Errorf("distribution: could not convert week [%s] to int: %v", vars["week"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if week < 1 || week > 13 {
+		week = 1
+	}
+
+	// was there a reference lap given?
+	refLap, refName := getReferenceLap(req)
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value := req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("distribution: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// are there any individually marked drivers given?
+	drivers := strings.Split(req.URL.Query().Get("drivers"), ",")
+
+	// is there a team given?
+	team := req.URL.Query().Get("team")
+
+	// were there any track or car details requested?
+	enrichment, err := getEnrichment(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants := enrichment.Variants()
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && distribution.IsAvailable(colorScheme, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, distribution.Filename(seasonID, week, team, variants...))
+		return
+	}
+	// lock global mutex
+	distributionMutex.Lock()
+	defer distributionMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && distribution.IsAvailable(colorScheme, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, distribution.Filename(seasonID, week, team, variants...))
+		return
+	}
+
+	// create/update distribution image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("distribution: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	raceweek, track, err := h.getRaceWeek(seasonID, week-1)
+	if err != nil {
+		log.Debugf("distribution: could not get raceweek for season[%d], week[%d]: %v", seasonID, week-1, err)
+		raceweek.RaceWeek = week - 1
+		raceweek.LastUpdate = time.Now()
+		track.Name = "starting soon..."
+	}
+	if enrichment.CarLogos {
+		enrichment.Cars = h.getRaceWeekCars(raceweek.RaceWeekID)
+	}
+	raceweekLaptimes, err := h.getRaceWeekFastestRaceLaptimes(seasonID, week-1)
+	if err != nil {
+		log.Errorf("distribution: could not get raceweek fastest race laptimes: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// collect laptimes of all drivers per division, 1-10, plus all of them together
+	all := distribution.DataSet{Division: "All"}
+	divisions := make([]distribution.DataSet, 10)
+	for _, rl := range raceweekLaptimes {
+		if rl.Laptime <= 100 {
+			continue
+		}
+		marked := isDriverMarked(drivers, rl.Driver) || (rl.Driver.Team == team && len(team) > 0)
+
+		all.Laptimes = append(all.Laptimes, rl.Laptime)
+		if marked {
+			all.Marked = append(all.Marked, distribution.DataMark{Driver: rl.Driver.Name, Laptime: rl.Laptime})
+		}
+		if rl.Driver.Division < 1 || rl.Driver.Division > 10 {
+			continue // no division assigned
+		}
+		division := &divisions[rl.Driver.Division-1]
+		division.Division = fmt.Sprintf("Division %d", rl.Driver.Division)
+		division.Laptimes = append(division.Laptimes, rl.Laptime)
+		if marked {
+			division.Marked = append(division.Marked, distribution.DataMark{Driver: rl.Driver.Name, Laptime: rl.Laptime})
+		}
+	}
+	data := make([]distribution.DataSet, 0)
+	for _, division := range divisions {
+		if len(division.Laptimes) > 0 {
+			data = append(data, division)
+		}
+	}
+	data = append(data, all)
+
+	reference := distribution.DataMark{Driver: refName, Laptime: refLap}
+
+	d := distribution.New(colorScheme, team, season, raceweek, track, data, reference)
+	d.Variants = variants
+	d.Enrichment = enrichment
+	if err := d.Draw(); err != nil {
+		log.Errorf("distribution: could not create weekly laptime distribution: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, distribution.Filename(seasonID, week, team, variants...))
+}
@@ -0,0 +1,192 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image/top"
+	"github.com/JamesClonk/iRvisualizer/log"
+)
+
+// divisionAll selects all divisions, with the leaders of each of them side by side
+const divisionAll = -1
+
+// divisionsPerSection is how many divisions fit side by side into a single section of a top image
+const divisionsPerSection = 4
+
+// getDivision reads the optional "division" query parameter, either a division 1-10 or "all".
+// Both the divisions and car classes of a season can not be drawn side by side at the same time
+func getDivision(req *http.Request, carClass int) (int, error) {
+	value := req.URL.Query().Get("division")
+	if len(value) == 0 {
+		return 0, nil
+	}
+	division := divisionAll
+	if strings.ToLower(value) != "all" {
+		var err error
+		division, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("could not convert division [%s] to int: %v", value, err)
+			return 0, err
+		}
+		if division < 1 || division > 10 {
+			return 0, fmt.Errorf("invalid division [%s], must be between 1 and 10", value)
+		}
+	}
+	if division == divisionAll && carClass == carClassAll {
+		return 0, fmt.Errorf("division [all] can not be combined with carClass [all]")
+	}
+	return division, nil
+}
+
+// divisionVariants returns the image file variants for a division selection, none if there is none
+func divisionVariants(division int) []string {
+	switch {
+	case division == divisionAll:
+		return []string{"divisions"}
+	case division > 0:
+		return []string{fmt.Sprintf("division_%d", division)}
+	}
+	return []string{}
+}
+
+// divisionName returns a readable name of a division
+func divisionName(division int) string {
+	return fmt.Sprintf("Division %d", division)
+}
+
+// hasDivision checks if a driver is in a division, always true for 0 / no division
+func hasDivision(division int, driver database.Driver) bool {
+	if division == 0 {
+		return true
+	}
+	return driver.Division == division
+}
+
+// divisionSelection returns the divisions to draw for a division query parameter out of all given drivers, 0 for all divisions combined
+func divisionSelection(division int, drivers []database.Driver) []int {
+	if division != divisionAll {
+		return []int{division}
+	}
+	known := make(map[int]bool)
+	divisions := make([]int, 0)
+	for _, driver := range drivers {
+		if driver.Division < 1 || driver.Division > 10 || known[driver.Division] {
+			continue // no division assigned, or already known
+		}
+		known[driver.Division] = true
+		divisions = append(divisions, driver.Division)
+	}
+	sort.Ints(divisions)
+	if len(divisions) == 0 {
+		return []int{0} // no divisions known yet
+	}
+	return divisions
+}
+
+// summaryDrivers returns the drivers of all given summaries
+func summaryDrivers(summaries []database.Summary) []database.Driver {
+	drivers := make([]database.Driver, 0)
+	for _, summary := range summaries {
+		drivers = append(drivers, summary.Driver)
+	}
+	return drivers
+}
+
+// standingDrivers returns the drivers of all given standings
+func standingDrivers(standings ...[]standing) []database.Driver {
+	drivers := make([]database.Driver, 0)
+	for _, list := range standings {
+		for _, s := range list {
+			drivers = append(drivers, s.Driver)
+		}
+	}
+	return drivers
+}
+
+// divisionSummaries returns only the summaries of drivers in a division
+func divisionSummaries(division int, summaries []database.Summary) []database.Summary {
+	filtered := make([]database.Summary, 0)
+	for _, summary := range summaries {
+		if hasDivision(division, summary.Driver) {
+			filtered = append(filtered, summary)
+		}
+	}
+	return filtered
+}
+
+// divisionLaptimes returns only the laptimes of drivers in a division
+func divisionLaptimes(division int, laptimes []database.FastestLaptime) []database.FastestLaptime {
+	filtered := make([]database.FastestLaptime, 0)
+	for _, laptime := range laptimes {
+		if hasDivision(division, laptime.Driver) {
+			filtered = append(filtered, laptime)
+		}
+	}
+	return filtered
+}
+
+// divisionStandings returns only the standings of drivers in a division
+func divisionStandings(division int, standings []standing) []standing {
+	filtered := make([]standing, 0)
+	for _, s := range standings {
+		if hasDivision(division, s.Driver) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// topDivisions builds the datasets of a top image for the selected divisions, either all of them side by side or only a single one
+func topDivisions(division int, drivers []database.Driver, build func(division int) []top.DataSet) []top.DataSet {
+	if division != divisionAll {
+		return build(division)
+	}
+	divisions := divisionSelection(division, drivers)
+	if divisions[0] == 0 {
+		return build(0) // no divisions known yet
+	}
+	data := make(map[int][]top.DataSet)
+	for _, d := range divisions {
+		data[d] = build(d)
+	}
+	return divisionSections(divisions, data)
+}
+
+// divisionSections rearranges the top datasets of each division into one section per dataset,
+// with the leaders of all divisions side by side
+func divisionSections(divisions []int, data map[int][]top.DataSet) []top.DataSet {
+	sections := make([]top.DataSet, 0)
+	if len(divisions) == 0 {
+		return sections
+	}
+	for d := range data[divisions[0]] {
+		for start := 0; start < len(divisions); start += divisionsPerSection {
+			end := start + divisionsPerSection
+			if end > len(divisions) {
+				end = len(divisions)
+			}
+			title := data[divisions[0]][d].Title
+			if len(data[divisions[0]][d].Section) > 0 { // keep car class name
+				title = fmt.Sprintf("%s - %s", data[divisions[0]][d].Section, title)
+			}
+			if len(divisions) > divisionsPerSection {
+				title = fmt.Sprintf("%s - Divisions %d to %d", title, divisions[start], divisions[end-1])
+			}
+			for _, division := range divisions[start:end] {
+				dataset := data[division][d]
+				sections = append(sections, top.DataSet{
+					Section: title,
+					Title:   divisionName(division),
+					Icons:   dataset.Icons,
+					Rows:    dataset.Rows,
+				})
+			}
+		}
+	}
+	return sections
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/gorilla/mux"
+)
+
+// driverSearch looks up the drivers of a season, either by the "id" query parameter or by a (partial) name in the "name" query parameter.
+// The IDs of all found drivers can be used to mark them in any image with the "drivers" query parameter
+func (h *Handler) driverSearch(rw http.ResponseWriter, req *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(req)["seasonID"])
+	if err != nil {
+		log.Errorf("drivers: could not convert seasonID [%s] to int: %v", mux.Vars(req)["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	// was there an id given?
+	driverID := 0
+	value := req.URL.Query().Get("id")
+	if len(value) > 0 {
+		driverID, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("drivers: could not convert id [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// was there a name given?
+	name := strings.ToLower(strings.TrimSpace(req.URL.Query().Get("name")))
+	if driverID == 0 && len(name) < 2 {
+		h.failure(rw, req, fmt.Errorf("either an id or a name with at least 2 characters is required"))
+		return
+	}
+
+	// was there a limit given?
+	limit := 25
+	value = req.URL.Query().Get("limit")
+	if len(value) > 0 {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("drivers: could not convert limit [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	summaries, err := h.getSeasonSummaries(seasonID)
+	if err != nil {
+		log.Errorf("drivers: could not get season summaries for season[%d]: %v", seasonID, err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// collect all drivers of the season, with their most recent team and division
+	drivers := make(map[int]database.Driver)
+	for _, summary := range summaries {
+		drivers[summary.Driver.DriverID] = summary.Driver
+	}
+
+	result := make([]database.Driver, 0)
+	for _, driver := range drivers {
+		if driverID > 0 && driver.DriverID != driverID {
+			continue
+		}
+		if len(name) > 0 && !strings.Contains(strings.ToLower(driver.Name), name) {
+			continue
+		}
+		result = append(result, driver)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name == result[j].Name {
+			return result[i].DriverID < result[j].DriverID
+		}
+		return result[i].Name < result[j].Name
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	h.writeJson(rw, req, result)
+}
@@ -0,0 +1,305 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/log"
+)
+
+// eligibility is the minimum participation a driver needs to be ranked in a top list or summary.
+// Races are counted out of all race results with at least one lap completed, official or not,
+// since the summaries of drivers only include their official races
+type eligibility struct {
+	MinRaces         int
+	MinLaps          int
+	MinOfficialRaces int
+}
+
+// summaryEligibility is the minimum participation to be listed in a summary, set by configuration
+var summaryEligibility = eligibility{}
+
+// eligibilityRules are the names of all eligibility rules, as used by configuration
+var eligibilityRules = []string{"races", "laps", "official"}
+
+// merge returns the stricter rule of both eligibilities
+func (e eligibility) merge(other eligibility) eligibility {
+	if other.MinRaces > e.MinRaces {
+		e.MinRaces = other.MinRaces
+	}
+	if other.MinLaps > e.MinLaps {
+		e.MinLaps = other.MinLaps
+	}
+	if other.MinOfficialRaces > e.MinOfficialRaces {
+		e.MinOfficialRaces = other.MinOfficialRaces
+	}
+	return e
+}
+
+// isSet checks if there is any minimum participation required at all
+func (e eligibility) isSet() bool {
+	return e.MinRaces > 0 || e.MinLaps > 0 || e.MinOfficialRaces > 0
+}
+
+// needsRaces checks if the races of each driver have to be counted to check the minimum participation
+func (e eligibility) needsRaces() bool {
+	return e.MinRaces > 0 || e.MinOfficialRaces > 0
+}
+
+// eligible checks if the summary of a driver meets the minimum participation, races are counted per driver
+func (e eligibility) eligible(summary database.Summary, races map[int]raceCount) bool {
+	return races[summary.Driver.DriverID].All >= e.MinRaces &&
+		summary.LapsCompleted >= e.MinLaps &&
+		races[summary.Driver.DriverID].Official >= e.MinOfficialRaces
+}
+
+// String describes the minimum participation, like "min. 3 races, 1 official race", or nothing if there is none.
+// Races include unofficial ones, official races only the official ones
+func (e eligibility) String() string {
+	rules := make([]string, 0)
+	if e.MinRaces > 0 {
+		rules = append(rules, plural(e.MinRaces, "race"))
+	}
+	if e.MinLaps > 0 {
+		rules = append(rules, plural(e.MinLaps, "lap"))
+	}
+	if e.MinOfficialRaces > 0 {
+		rules = append(rules, plural(e.MinOfficialRaces, "official race"))
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return "min. " + strings.Join(rules, ", ")
+}
+
+// note describes the minimum participation for the footer of an image, or nothing if there is none.
+// Its races are all races of a driver including unofficial ones, unlike the races in the summaries
+func (e eligibility) note() string {
+	if !e.isSet() {
+		return ""
+	}
+	return "Eligibility: " + e.String()
+}
+
+// variants returns the image file variants of an eligibility, none if there is no minimum participation
+func (e eligibility) variants() []string {
+	if !e.isSet() {
+		return []string{}
+	}
+	return []string{fmt.Sprintf("min_%d_%d_%d", e.MinRaces, e.MinLaps, e.MinOfficialRaces)}
+}
+
+func plural(count int, word string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, word)
+	}
+	return fmt.Sprintf("%d %ss", count, word)
+}
+
+// getEligibility reads the optional "minRaces", "minLaps" and "minOfficialRaces" query parameters
+func getEligibility(req *http.Request) (eligibility, error) {
+	e := eligibility{}
+	for param, value := range map[string]*int{
+		"minRaces":         &e.MinRaces,
+		"minLaps":          &e.MinLaps,
+		"minOfficialRaces": &e.MinOfficialRaces,
+	} {
+		if len(req.URL.Query().Get(param)) == 0 {
+			continue
+		}
+		min, err := strconv.Atoi(req.URL.Query().Get(param))
+		if err != nil {
+			log.Errorf("could not convert %s [%s] to int: %v", param, req.URL.Query().Get(param), err)
+			return e, err
+		}
+		if min < 0 {
+			return e, fmt.Errorf("invalid %s [%d], must not be negative", param, min)
+		}
+		*value = min
+	}
+	return e, nil
+}
+
+// parseEligibility parses an eligibility out of a configuration like "races:3,laps:20,official:1"
+func parseEligibility(value string) (eligibility, error) {
+	e := eligibility{}
+	for _, rule := range strings.Split(value, ",") {
+		if len(strings.TrimSpace(rule)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(rule, ":", 2)
+		if len(parts) != 2 {
+			return e, fmt.Errorf("invalid eligibility rule [%s], must be like races:3", rule)
+		}
+		min, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || min < 0 {
+			return e, fmt.Errorf("invalid eligibility rule [%s], must have a positive number", rule)
+		}
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "races":
+			e.MinRaces = min
+		case "laps":
+			e.MinLaps = min
+		case "official":
+			e.MinOfficialRaces = min
+		default:
+			return e, fmt.Errorf("invalid eligibility rule [%s], must be one of %s", rule, strings.Join(eligibilityRules, ", "))
+		}
+	}
+	return e, nil
+}
+
+// AddEligibility sets the minimum participation of metrics or summaries out of a configuration like "incidents=races:3;wins=official:1;summary=laps:10"
+func AddEligibility(config string) error {
+	for _, entry := range strings.Split(config, ";") {
+		if len(strings.TrimSpace(entry)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid eligibility [%s], must be like metric=races:3,laps:20", entry)
+		}
+		e, err := parseEligibility(parts[1])
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if name == "summary" {
+			summaryEligibility = e
+		} else {
+			metric, ok := topMetrics[name]
+			if !ok {
+				return fmt.Errorf("invalid metric [%s], must be one of summary, %s", name, strings.Join(topMetricNames(), ", "))
+			}
+			metric.Eligibility = e
+			topMetrics[name] = metric
+		}
+		log.Infof("set eligibility of [%s]: %s", name, e)
+	}
+	return nil
+}
+
+// eligibleSummaries returns only the summaries of drivers meeting the minimum participation
+func eligibleSummaries(e eligibility, summaries []database.Summary, races map[int]raceCount) []database.Summary {
+	if !e.isSet() {
+		return summaries
+	}
+	eligible := make([]database.Summary, 0)
+	for _, summary := range summaries {
+		if e.eligible(summary, races) {
+			eligible = append(eligible, summary)
+		}
+	}
+	return eligible
+}
+
+// eligibleLaptimes returns only the laptimes of drivers with a summary meeting the minimum participation
+func eligibleLaptimes(e eligibility, laptimes []database.FastestLaptime, summaries []database.Summary, races map[int]raceCount) []database.FastestLaptime {
+	if !e.isSet() {
+		return laptimes
+	}
+	drivers := make(map[int]bool)
+	for _, summary := range summaries {
+		if e.eligible(summary, races) {
+			drivers[summary.Driver.DriverID] = true
+		}
+	}
+	eligible := make([]database.FastestLaptime, 0)
+	for _, laptime := range laptimes {
+		if drivers[laptime.Driver.DriverID] {
+			eligible = append(eligible, laptime)
+		}
+	}
+	return eligible
+}
+
+// raceCount is the number of races of a driver, all of them and only the official ones
+type raceCount struct {
+	All      int
+	Official int
+}
+
+// raceCounts counts the races of each driver with at least one lap completed in the given raceweeks,
+// only weeks with a track of the given category are included
+func (h *Handler) raceCounts(seasonID int, weeks []int, category string) (map[int]raceCount, error) {
+	races := make(map[int]raceCount)
+	for _, week := range weeks {
+		isCategory, err := h.isCategoryWeek(seasonID, week, category)
+		if err != nil {
+			return nil, err
+		}
+		if !isCategory {
+			continue
+		}
+		results, err := h.getRaceWeekResults(seasonID, week)
+		if err != nil {
+			return nil, err
+		}
+		official := make(map[int]bool)
+		for _, result := range results {
+			official[result.SubsessionID] = result.Official
+		}
+		raceResults, err := h.getRaceResults(seasonID, week)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range raceResults {
+			if result.LapsCompleted <= 0 {
+				continue
+			}
+			count := races[result.Driver.DriverID]
+			count.All++
+			if official[result.SubsessionID] {
+				count.Official++
+			}
+			races[result.Driver.DriverID] = count
+		}
+	}
+	return races, nil
+}
+
+// eligibilityNote describes the minimum participation of all given metrics for the footer of a top image,
+// either a single note if all of them share the same, or a note for each metric with one
+func eligibilityNote(metrics []string, required eligibility) string {
+	notes := make([]string, 0)
+	shared := true
+	for _, name := range metrics {
+		e := topMetrics[name].Eligibility.merge(required)
+		if e != topMetrics[metrics[0]].Eligibility.merge(required) {
+			shared = false
+		}
+		if e.isSet() {
+			notes = append(notes, fmt.Sprintf("%s: %s", topMetrics[name].Title, e))
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	if shared {
+		return topMetrics[metrics[0]].Eligibility.merge(required).note()
+	}
+	return "Eligibility - " + strings.Join(notes, ", ")
+}
+
+// eligibilityVariants returns the image file variants for the minimum participation of each of the given metrics,
+// with the required minimum merged into their configured one
+func eligibilityVariants(metrics []string, required eligibility) []string {
+	variants := make([]string, 0)
+	shared := true
+	for _, name := range metrics {
+		e := topMetrics[name].Eligibility.merge(required)
+		if e != topMetrics[metrics[0]].Eligibility.merge(required) {
+			shared = false
+		}
+		for _, variant := range e.variants() {
+			variants = append(variants, name+"_"+variant)
+		}
+	}
+	if shared && len(metrics) > 0 {
+		return topMetrics[metrics[0]].Eligibility.merge(required).variants()
+	}
+	return variants
+}
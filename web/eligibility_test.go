@@ -0,0 +1,110 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Eligibility_Parse(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected eligibility
+		err      bool
+	}{
+		{"", eligibility{}, false},
+		{"races:3", eligibility{MinRaces: 3}, false},
+		{"races:3,laps:20,official:1", eligibility{MinRaces: 3, MinLaps: 20, MinOfficialRaces: 1}, false},
+		{" Races : 2 , ,laps:0", eligibility{MinRaces: 2}, false},
+		{"races", eligibility{}, true},
+		{"races:-1", eligibility{}, true},
+		{"races:many", eligibility{}, true},
+		{"wins:1", eligibility{}, true},
+	}
+
+	for _, test := range tests {
+		e, err := parseEligibility(test.value)
+		if test.err {
+			assert.Error(t, err, test.value)
+			continue
+		}
+		assert.NoError(t, err, test.value)
+		assert.Equal(t, test.expected, e, test.value)
+	}
+}
+
+func Test_Eligibility_Add(t *testing.T) {
+	tests := []struct {
+		config   string
+		name     string
+		expected eligibility
+		err      bool
+	}{
+		{"", "incidents", eligibility{MinRaces: 3}, false},
+		{"wins=races:2", "wins", eligibility{MinRaces: 2}, false},
+		{"summary=laps:10;incidents=official:1", "summary", eligibility{MinLaps: 10}, false},
+		{"summary=laps:10;incidents=official:1", "incidents", eligibility{MinOfficialRaces: 1}, false},
+		{"wins", "wins", eligibility{}, true},
+		{"wins=races", "wins", eligibility{}, true},
+		{"unknown=races:1", "unknown", eligibility{}, true},
+	}
+
+	for _, test := range tests {
+		metrics := make(map[string]topMetric)
+		for name, metric := range topMetrics {
+			metrics[name] = metric
+		}
+		summary := summaryEligibility
+
+		err := AddEligibility(test.config)
+		if test.err {
+			assert.Error(t, err, test.config)
+		} else {
+			assert.NoError(t, err, test.config)
+			if test.name == "summary" {
+				assert.Equal(t, test.expected, summaryEligibility, test.config)
+			} else {
+				assert.Equal(t, test.expected, topMetrics[test.name].Eligibility, test.config)
+			}
+		}
+
+		topMetrics = metrics
+		summaryEligibility = summary
+	}
+}
+
+func Test_Eligibility_Merge(t *testing.T) {
+	tests := []struct {
+		a        eligibility
+		b        eligibility
+		expected eligibility
+	}{
+		{eligibility{}, eligibility{}, eligibility{}},
+		{eligibility{MinRaces: 3}, eligibility{}, eligibility{MinRaces: 3}},
+		{eligibility{}, eligibility{MinLaps: 10}, eligibility{MinLaps: 10}},
+		{eligibility{MinRaces: 3, MinLaps: 5}, eligibility{MinRaces: 1, MinLaps: 10, MinOfficialRaces: 2}, eligibility{MinRaces: 3, MinLaps: 10, MinOfficialRaces: 2}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.a.merge(test.b))
+		assert.Equal(t, test.expected, test.b.merge(test.a))
+	}
+}
+
+func Test_Eligibility_Variants(t *testing.T) {
+	tests := []struct {
+		metrics  []string
+		required eligibility
+		expected []string
+	}{
+		{[]string{"wins", "podiums"}, eligibility{}, []string{}},
+		{[]string{"wins", "podiums"}, eligibility{MinRaces: 2}, []string{"min_2_0_0"}},
+		{[]string{"incidents"}, eligibility{MinRaces: 1}, []string{"min_3_0_0"}},
+		{[]string{"wins", "incidents"}, eligibility{}, []string{"incidents_min_3_0_0"}},
+		{[]string{"wins", "incidents"}, eligibility{MinLaps: 10}, []string{"wins_min_0_10_0", "incidents_min_3_10_0"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, eligibilityVariants(test.metrics, test.required), test.metrics)
+	}
+}
@@ -0,0 +1,246 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image/h2h"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/scoring"
+	"github.com/gorilla/mux"
+)
+
+var h2hMutex = &sync.Mutex{}
+
+// getH2HDrivers reads the "drivers" query parameter, 2 up to h2h.MaxDrivers driver IDs or names, and returns its image file variants
+func getH2HDrivers(req *http.Request) ([]string, []string, error) {
+	drivers := make([]string, 0)
+	for _, driver := range strings.Split(req.URL.Query().Get("drivers"), ",") {
+		driver = strings.TrimSpace(driver)
+		if len(driver) > 0 {
+			drivers = append(drivers, driver)
+		}
+	}
+	if len(drivers) < 2 || len(drivers) > h2h.MaxDrivers {
+		return nil, nil, fmt.Errorf("drivers must contain 2 to %d driver IDs or names", h2h.MaxDrivers)
+	}
+
+	names := make([]string, 0, len(drivers))
+	for _, driver := range drivers {
+		names = append(names, slug(driver))
+	}
+	return drivers, []string{"drivers_" + strings.Join(names, "_")}, nil
+}
+
+// h2hData collects the head-to-head comparison of the given drivers out of the official race results of a season,
+// in the same order as the drivers were given. Returns the data and the number of weeks with race results
+func (h *Handler) h2hData(seasonID int, drivers []string, rules scoring.Rules) ([]h2h.DataRow, int, error) {
+	weeklyResults := make([][]database.RaceResult, 0)
+	for week := 0; week < 13; week++ { // allow for leap seasons with 13 official weeks, like 2020S3
+		raceweekResults, err := h.getRaceWeekResults(seasonID, week)
+		if err != nil {
+			log.Errorf("could not get raceweek results for week [%d]: %v", week+1, err)
+			return nil, 0, err
+		}
+		official := make(map[int]bool)
+		for _, result := range raceweekResults {
+			official[result.SubsessionID] = result.Official
+		}
+		results, err := h.getRaceResults(seasonID, week)
+		if err != nil {
+			log.Errorf("could not get race results for week [%d]: %v", week+1, err)
+			return nil, 0, err
+		}
+		// only official races the drivers actually took part in, the same as the summaries
+		officialResults := make([]database.RaceResult, 0)
+		for _, result := range results {
+			if official[result.SubsessionID] && result.LapsCompleted > 0 {
+				officialResults = append(officialResults, result)
+			}
+		}
+		weeklyResults = append(weeklyResults, officialResults)
+	}
+
+	// which drivers are we looking for?
+	found := make([]database.Driver, len(drivers))
+	weeks := 0
+	for week, results := range weeklyResults {
+		if len(results) > 0 {
+			weeks = week + 1
+		}
+		for _, result := range results {
+			for d := range drivers {
+				if found[d].DriverID == 0 && isDriverMarked(drivers[d:d+1], result.Driver) {
+					found[d] = result.Driver
+				}
+			}
+		}
+	}
+	for d, driver := range found {
+		if driver.DriverID == 0 {
+			return nil, 0, fmt.Errorf("driver [%s] has no race results in season [%d]", drivers[d], seasonID)
+		}
+	}
+	index := make(map[int]int)
+	for d, driver := range found {
+		if _, ok := index[driver.DriverID]; ok {
+			return nil, 0, fmt.Errorf("driver [%s] was given more than once", driver.Name)
+		}
+		index[driver.DriverID] = d
+	}
+
+	data := make([]h2h.DataRow, len(found))
+	for d, driver := range found {
+		data[d] = h2h.DataRow{
+			Driver:      driver.Name,
+			ChampPoints: make([]int, weeks),
+			BestLaps:    make([]database.Laptime, weeks),
+			IRatings:    make([]int, weeks),
+			Ahead:       make([]int, len(found)),
+			Shared:      make([]int, len(found)),
+		}
+	}
+
+	for week := 0; week < weeks; week++ {
+		champPoints := make([][]int, len(found))
+		subsessions := make(map[int][]database.RaceResult)
+		for _, result := range weeklyResults[week] {
+			d, ok := index[result.Driver.DriverID]
+			if !ok {
+				continue
+			}
+			row := &data[d]
+			if row.Races == 0 {
+				row.IRatingBefore = result.IRatingBefore
+			}
+			row.Races++
+			if result.FinishingPosition == 0 {
+				row.Wins++
+			}
+			row.Laps += result.LapsCompleted
+			row.Incidents += result.Incidents
+			row.IRatingAfter = result.IRatingAfter
+			row.IRatings[week] = result.IRatingAfter
+			if result.BestLaptime > 0 && (row.BestLaps[week] == 0 || result.BestLaptime < row.BestLaps[week]) {
+				row.BestLaps[week] = result.BestLaptime
+			}
+			champPoints[d] = append(champPoints[d], result.ChampPoints)
+			subsessions[result.SubsessionID] = append(subsessions[result.SubsessionID], result)
+		}
+
+		// weekly championship points, with the given scoring rules
+		for d := range data {
+			data[d].ChampPoints[week] = -1
+			if len(champPoints[d]) > 0 {
+				data[d].ChampPoints[week] = int(math.Floor(rules.WeeklyPoints(champPoints[d])))
+			}
+		}
+
+		// who finished ahead in the races they shared?
+		for _, results := range subsessions {
+			for _, a := range results {
+				for _, b := range results {
+					if a.Driver.DriverID == b.Driver.DriverID {
+						continue
+					}
+					i, j := index[a.Driver.DriverID], index[b.Driver.DriverID]
+					data[i].Shared[j]++
+					if a.FinishingPosition < b.FinishingPosition {
+						data[i].Ahead[j]++
+					}
+				}
+			}
+		}
+	}
+	return data, weeks, nil
+}
+
+func (h *Handler) seasonH2H(rw http.ResponseWriter, req *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(req)["seasonID"])
+	if err != nil {
+		log.Errorf("h2h: could not convert seasonID [%s] to int: %v", mux.Vars(req)["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	// which drivers are compared?
+	drivers, variants, err := getH2HDrivers(req)
+	if err != nil {
+		log.Errorf("h2h: could not get drivers: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// were there any scoring rules given?
+	rules, rulesVariants, err := getRules(req, "iracing")
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, rulesVariants...)
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value := req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("h2h: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// is there a team given?
+	team := req.URL.Query().Get("team")
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && h2h.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, h2h.Filename(seasonID, team, variants...))
+		return
+	}
+	// lock global mutex
+	h2hMutex.Lock()
+	defer h2hMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && h2h.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, h2h.Filename(seasonID, team, variants...))
+		return
+	}
+
+	// create/update head-to-head image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("h2h: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	data, weeks, err := h.h2hData(seasonID, drivers, rules)
+	if err != nil {
+		log.Errorf("h2h: could not collect head-to-head data: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	comparison := h2h.New(colorScheme, team, season, weeks, data, variants...)
+	if err := comparison.Draw(); err != nil {
+		log.Errorf("h2h: could not create head-to-head image: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, h2h.Filename(seasonID, team, variants...))
+}
@@ -1,21 +1,103 @@
 package web
 
 import (
+	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	scheme "github.com/JamesClonk/iRvisualizer/image/color"
 	"github.com/JamesClonk/iRvisualizer/image/heatmap"
 	"github.com/JamesClonk/iRvisualizer/log"
 	"github.com/gorilla/mux"
-	"github.com/robfig/cron"
 )
 
 var heatmapMutex = &sync.Mutex{}
 
+// getHeatmapOptions reads the optional timezone, metric, layout, color scale, legend and track details query parameters
+func getHeatmapOptions(req *http.Request) (heatmap.Options, error) {
+	options := heatmap.DefaultOptions()
+
+	// was there a timezone given?
+	location, err := getLocation(req)
+	if err != nil {
+		log.Errorf("could not load timezone [%s]: %v", req.URL.Query().Get("tz"), err)
+		return options, err
+	}
+	options.Location = location
+
+	// was there a metric given?
+	metric := strings.ToLower(req.URL.Query().Get("metric"))
+	if len(metric) > 0 {
+		if !heatmap.IsMetric(metric) {
+			err := fmt.Errorf("invalid metric [%s], must be one of sof, drivers, splits or official_rate", metric)
+			log.Errorf("%v", err)
+			return options, err
+		}
+		options.Metric = metric
+	}
+
+	// was there a layout given?
+	layout := strings.ToLower(req.URL.Query().Get("layout"))
+	if len(layout) > 0 {
+		if !heatmap.IsLayout(layout) {
+			err := fmt.Errorf("invalid layout [%s], must be one of cells or splits", layout)
+			log.Errorf("%v", err)
+			return options, err
+		}
+		options.Layout = layout
+	}
+	if options.Layout == heatmap.LayoutSplits {
+		options.Metric = heatmap.MetricSOF // split bars are always colored by SOF
+	}
+
+	// was there a gradient given?
+	gradient := strings.ToLower(strings.ReplaceAll(req.URL.Query().Get("gradient"), "#", ""))
+	if len(gradient) > 0 {
+		if _, err := scheme.GetGradient(gradient); err != nil {
+			log.Errorf("%v", err)
+			return options, err
+		}
+		options.Gradient = gradient
+	}
+
+	// was there a scale given?
+	scale := strings.ToLower(req.URL.Query().Get("scale"))
+	if len(scale) > 0 {
+		if !image.IsScale(scale) {
+			err := fmt.Errorf("invalid scale [%s], must be one of linear, log or quantile", scale)
+			log.Errorf("%v", err)
+			return options, err
+		}
+		options.Scale = scale
+	}
+
+	// should the color scale be figured out from the data?
+	options.AutoScale = req.URL.Query().Get("minSOF") == "auto" || req.URL.Query().Get("maxSOF") == "auto"
+
+	// were there any track or car details requested?
+	options.Enrichment, err = getEnrichment(req)
+	if err != nil {
+		return options, err
+	}
+
+	// should a legend be drawn?
+	value := req.URL.Query().Get("legend")
+	if len(value) > 0 {
+		options.Legend, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("could not convert legend [%s] to bool: %v", value, err)
+			return options, err
+		}
+	}
+	return options, nil
+}
+
 func (h *Handler) weeklyHeatmap(rw http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	seasonID, err := strconv.Atoi(vars["seasonID"])
@@ -43,7 +125,7 @@ func (h *Handler) weeklyHeatmap(rw http.ResponseWriter, req *http.Request) {
 	// was there a minSOF given?
 	minSOF := 1000
 	value := req.URL.Query().Get("minSOF")
-	if len(value) > 0 {
+	if len(value) > 0 && value != "auto" {
 		minSOF, err = strconv.Atoi(value)
 		if err != nil {
 			log.Errorf("could not convert minSOF [%s] to int: %v", value, err)
@@ -55,7 +137,7 @@ func (h *Handler) weeklyHeatmap(rw http.ResponseWriter, req *http.Request) {
 	// was there a maxSOF given?
 	maxSOF := 2700
 	value = req.URL.Query().Get("maxSOF")
-	if len(value) > 0 {
+	if len(value) > 0 && value != "auto" {
 		maxSOF, err = strconv.Atoi(value)
 		if err != nil {
 			log.Errorf("could not convert maxSOF [%s] to int: %v", value, err)
@@ -76,18 +158,26 @@ func (h *Handler) weeklyHeatmap(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	// were there any heatmap options given?
+	options, err := getHeatmapOptions(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants := options.Variants()
+
 	// do we need to update the image file?
 	// check if file already exists and is up-to-date, serve it immediately if yes
-	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, week) {
-		http.ServeFile(rw, req, heatmap.Filename(seasonID, week))
+	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, week, variants...) {
+		http.ServeFile(rw, req, heatmap.Filename(seasonID, week, variants...))
 		return
 	}
 	// lock global mutex
 	heatmapMutex.Lock()
 	defer heatmapMutex.Unlock()
 	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
-	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, week) {
-		http.ServeFile(rw, req, heatmap.Filename(seasonID, week))
+	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, week, variants...) {
+		http.ServeFile(rw, req, heatmap.Filename(seasonID, week, variants...))
 		return
 	}
 
@@ -104,6 +194,9 @@ func (h *Handler) weeklyHeatmap(rw http.ResponseWriter, req *http.Request) {
 		raceweek.RaceWeek = week - 1
 		track.Name = "starting soon..."
 	}
+	if options.Enrichment.CarLogos {
+		options.Enrichment.Cars = h.getRaceWeekCars(raceweek.RaceWeekID)
+	}
 	results, err := h.getRaceWeekResults(seasonID, week-1)
 	if err != nil {
 		log.Errorf("heatmap: could not get raceweek for season[%d], week[%d]: %v", seasonID, week-1, err)
@@ -111,6 +204,7 @@ func (h *Handler) weeklyHeatmap(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 	hm := heatmap.New(colorScheme, season, raceweek, track, results)
+	hm.Options = options
 	if err := hm.Draw(minSOF, maxSOF, true); err != nil {
 		log.Errorf("could not create heatmap season[%d], week[%d]: %v", seasonID, week-1, err)
 		h.failure(rw, req, err)
@@ -118,7 +212,7 @@ func (h *Handler) weeklyHeatmap(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	// serve new/updated image
-	http.ServeFile(rw, req, heatmap.Filename(seasonID, week))
+	http.ServeFile(rw, req, heatmap.Filename(seasonID, week, variants...))
 }
 
 func (h *Handler) seasonalHeatmap(rw http.ResponseWriter, req *http.Request) {
@@ -139,7 +233,7 @@ func (h *Handler) seasonalHeatmap(rw http.ResponseWriter, req *http.Request) {
 	// was there a minSOF given?
 	minSOF := 900
 	value := req.URL.Query().Get("minSOF")
-	if len(value) > 0 {
+	if len(value) > 0 && value != "auto" {
 		minSOF, err = strconv.Atoi(value)
 		if err != nil {
 			log.Errorf("could not convert minSOF [%s] to int: %v", value, err)
@@ -151,7 +245,7 @@ func (h *Handler) seasonalHeatmap(rw http.ResponseWriter, req *http.Request) {
 	// was there a maxSOF given?
 	maxSOF := 2700
 	value = req.URL.Query().Get("maxSOF")
-	if len(value) > 0 {
+	if len(value) > 0 && value != "auto" {
 		maxSOF, err = strconv.Atoi(value)
 		if err != nil {
 			log.Errorf("could not convert maxSOF [%s] to int: %v", value, err)
@@ -172,18 +266,26 @@ func (h *Handler) seasonalHeatmap(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	// were there any heatmap options given?
+	options, err := getHeatmapOptions(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants := options.Variants()
+
 	// do we need to update the image file?
 	// check if file already exists and is up-to-date, serve it immediately if yes
-	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, -1) {
-		http.ServeFile(rw, req, heatmap.Filename(seasonID, -1))
+	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, -1, variants...) {
+		http.ServeFile(rw, req, heatmap.Filename(seasonID, -1, variants...))
 		return
 	}
 	// lock global mutex
 	heatmapMutex.Lock()
 	defer heatmapMutex.Unlock()
 	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
-	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, -1) {
-		http.ServeFile(rw, req, heatmap.Filename(seasonID, -1))
+	if !forceOverwrite && heatmap.IsAvailable(colorScheme, seasonID, -1, variants...) {
+		http.ServeFile(rw, req, heatmap.Filename(seasonID, -1, variants...))
 		return
 	}
 
@@ -195,108 +297,188 @@ func (h *Handler) seasonalHeatmap(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// figure out timeslots schedule
-	p := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
-	schedule, err := p.Parse(season.Timeslots)
+	// collect all weeks together, the heatmap averages each timeslot over all raceweeks
+	results := h.getSeasonResults(seasonID)
+
+	hm := heatmap.New(colorScheme, season, database.RaceWeek{RaceWeek: -1, LastUpdate: time.Now()}, database.Track{}, results)
+	hm.Options = options
+	if err := hm.Draw(minSOF, maxSOF, false); err != nil {
+		log.Errorf("could not create seasonal heatmap: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, heatmap.Filename(seasonID, -1, variants...))
+}
+
+func (h *Handler) seriesHeatmap(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	seriesID, err := strconv.Atoi(vars["seriesID"])
 	if err != nil {
-		log.Errorf("could not parse timeslot [%s] to crontab format: %v", season.Timeslots, err)
+		log.Errorf("could not convert seriesID [%s] to int: %v", vars["seriesID"], err)
 		h.failure(rw, req, err)
 		return
 	}
+	if seriesID < 1 || seriesID > 99 {
+		seriesID = 2
+	}
 
-	// sum/avg all weeks together
-	var weeksFound int
-	allResults := make([][]database.RaceWeekResult, 0)
-	for week := 0; week < 12; week++ {
-		rs, err := h.getRaceWeekResults(seasonID, week)
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a minSOF given?
+	minSOF := 900
+	value := req.URL.Query().Get("minSOF")
+	if len(value) > 0 && value != "auto" {
+		minSOF, err = strconv.Atoi(value)
 		if err != nil {
-			log.Debugf("seasonal heatmap: could not get raceweek results for season[%d], week[%d]: %v", seasonID, week, err)
-		}
-		if len(rs) > 0 {
-			weeksFound++
+			log.Errorf("could not convert minSOF [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
 		}
+	}
 
-		// sum splits
-		sessions := make(map[int]database.RaceWeekResult, 0)
-		for _, r := range rs {
-			if session, found := sessions[r.SessionID]; found {
-				session.SizeOfField += r.SizeOfField
-				if r.StrengthOfField > session.StrengthOfField {
-					session.StrengthOfField = r.StrengthOfField
-				}
-				sessions[r.SessionID] = session
-			} else {
-				sessions[r.SessionID] = r
-			}
+	// was there a maxSOF given?
+	maxSOF := 2700
+	value = req.URL.Query().Get("maxSOF")
+	if len(value) > 0 && value != "auto" {
+		maxSOF, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("could not convert maxSOF [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
 		}
+	}
 
-		// add to allResults collection
-		weekResults := make([]database.RaceWeekResult, 0)
-		for _, session := range sessions {
-			weekResults = append(weekResults, session)
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value = req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
 		}
-		allResults = append(allResults, weekResults)
-	}
-
-	// go through all timeslots and calculate final result
-	finalResults := make([]database.RaceWeekResult, 0)
-	start := database.WeekStart(season.StartDate.UTC().AddDate(0, 0, 7)).Add(-1 * time.Minute)
-	timeslots := make([]time.Time, 0)
-	next := schedule.Next(start)                             // get first timeslot
-	for next.Before(schedule.Next(start.AddDate(0, 0, 7))) { // collect all timeslots of 1 week
-		timeslots = append(timeslots, next)
-		next = schedule.Next(next)
-	}
-	sessionIdx := 0
-	for _, timeslot := range timeslots {
-		sessionIdx++
-		finalResult := database.RaceWeekResult{
-			SessionID:       sessionIdx,
-			StartTime:       timeslot,
-			Official:        false,
-			SizeOfField:     0,
-			StrengthOfField: 0,
+	}
+
+	// were there any heatmap options given?
+	options, err := getHeatmapOptions(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	// how many seasons should be averaged?
+	options.Seasons = 4
+	value = req.URL.Query().Get("seasons")
+	if len(value) > 0 {
+		options.Seasons, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("could not convert seasons [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
 		}
+	}
+	if options.Seasons < 1 || options.Seasons > 12 {
+		options.Seasons = 4
+	}
 
-		// see how many results exist for that timeslot
-		raceWeeksFound := make(map[int]bool, 0)
-		for _, results := range allResults {
-			for _, result := range results {
-				if timeslot.UTC().Weekday() == result.StartTime.UTC().Weekday() &&
-					timeslot.UTC().Hour() == result.StartTime.UTC().Hour() &&
-					timeslot.UTC().Minute() == result.StartTime.UTC().Minute() {
-					if result.Official {
-						finalResult.Official = true
-					}
-
-					finalResult.SizeOfField += result.SizeOfField
-					finalResult.StrengthOfField += result.StrengthOfField
-					raceWeeksFound[result.RaceWeekID] = true
-				}
-			}
+	// should it show the difference to the previous season instead?
+	value = req.URL.Query().Get("diff")
+	if len(value) > 0 {
+		options.Diff, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("could not convert diff [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
 		}
+	}
+	if strings.ToLower(req.URL.Query().Get("mode")) == "diff" {
+		options.Diff = true
+	}
+	if options.Diff {
+		options.Seasons = 1 // always compares the latest season with the previous one
+		options.Layout = heatmap.LayoutCells
+		if len(req.URL.Query().Get("metric")) == 0 {
+			options.Metric = heatmap.MetricDrivers // gained or lost drivers by default
+		}
+	}
 
-		// average size and sof
-		if len(raceWeeksFound) > 0 {
-			finalResult.SizeOfField = finalResult.SizeOfField / len(raceWeeksFound)
-			finalResult.StrengthOfField = finalResult.StrengthOfField / len(raceWeeksFound)
+	// get all seasons of the series that already started, latest first
+	seasons, err := h.getSeasons(seriesID)
+	if err != nil {
+		log.Errorf("could not get seasons: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	started := make([]database.Season, 0)
+	for _, season := range seasons {
+		if season.StartDate.Before(time.Now()) {
+			started = append(started, season)
 		}
+	}
+	sort.Slice(started, func(i, j int) bool {
+		return started[i].StartDate.After(started[j].StartDate)
+	})
+	if len(started) == 0 || (options.Diff && len(started) < 2) {
+		err := fmt.Errorf("not enough seasons found for series [%d]", seriesID)
+		log.Errorf("series heatmap: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	if len(started) < options.Seasons {
+		options.Seasons = len(started)
+	}
+	season := started[0]
+	variants := options.Variants()
 
-		finalResults = append(finalResults, finalResult)
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && heatmap.IsAvailable(colorScheme, season.SeasonID, -1, variants...) {
+		http.ServeFile(rw, req, heatmap.Filename(season.SeasonID, -1, variants...))
+		return
+	}
+	// lock global mutex
+	heatmapMutex.Lock()
+	defer heatmapMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && heatmap.IsAvailable(colorScheme, season.SeasonID, -1, variants...) {
+		http.ServeFile(rw, req, heatmap.Filename(season.SeasonID, -1, variants...))
+		return
 	}
 
-	// sort again to be on the safe side..
-	sort.Slice(finalResults, func(i, j int) bool {
-		return finalResults[i].StartTime.Before(finalResults[j].StartTime)
-	})
+	// collect all weeks of all seasons together, the heatmap averages each timeslot over all raceweeks
+	results := make([]database.RaceWeekResult, 0)
+	for _, s := range started[:options.Seasons] {
+		results = append(results, h.getSeasonResults(s.SeasonID)...)
+	}
 
-	hm := heatmap.New(colorScheme, season, database.RaceWeek{RaceWeek: -1, LastUpdate: time.Now()}, database.Track{}, finalResults)
+	hm := heatmap.New(colorScheme, season, database.RaceWeek{RaceWeek: -1, LastUpdate: time.Now()}, database.Track{}, results)
+	hm.Options = options
+	if options.Diff {
+		hm.Previous = h.getSeasonResults(started[1].SeasonID)
+	}
 	if err := hm.Draw(minSOF, maxSOF, false); err != nil {
-		log.Errorf("could not create seasonal heatmap: %v", err)
+		log.Errorf("could not create series heatmap: %v", err)
 		h.failure(rw, req, err)
 		return
 	}
 
 	// serve new/updated image
-	http.ServeFile(rw, req, heatmap.Filename(seasonID, -1))
+	http.ServeFile(rw, req, heatmap.Filename(season.SeasonID, -1, variants...))
+}
+
+// getSeasonResults collects the results of all raceweeks of a season
+func (h *Handler) getSeasonResults(seasonID int) []database.RaceWeekResult {
+	results := make([]database.RaceWeekResult, 0)
+	for week := 0; week < 12; week++ {
+		rs, err := h.getRaceWeekResults(seasonID, week)
+		if err != nil {
+			log.Debugf("heatmap: could not get raceweek results for season[%d], week[%d]: %v", seasonID, week, err)
+		}
+		results = append(results, rs...)
+	}
+	return results
 }
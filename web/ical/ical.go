@@ -0,0 +1,78 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event is a single VEVENT of an iCalendar (RFC 5545)
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+}
+
+// Calendar returns all events as an iCalendar (RFC 5545)
+func Calendar(name string, events []Event) []byte {
+	var data bytes.Buffer
+	line := func(content string) {
+		data.WriteString(fold(content))
+		data.WriteString("\r\n")
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//iRvisualizer//Race Calendar//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escape(name))
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + event.Start.UTC().Format("20060102T150405Z"))
+		line("DTEND:" + event.End.UTC().Format("20060102T150405Z"))
+		line("SUMMARY:" + escape(event.Summary))
+		if len(event.Description) > 0 {
+			line("DESCRIPTION:" + escape(event.Description))
+		}
+		if len(event.Location) > 0 {
+			line("LOCATION:" + escape(event.Location))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return data.Bytes()
+}
+
+// escape escapes text values, see RFC 5545 section 3.3.11
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// fold splits content lines longer than 75 octets, see RFC 5545 section 3.1
+func fold(content string) string {
+	var folded strings.Builder
+	length := 0
+	for _, r := range content {
+		size := len(string(r))
+		if length+size > 75 {
+			folded.WriteString("\r\n ")
+			length = 1
+		}
+		folded.WriteRune(r)
+		length += size
+	}
+	return folded.String()
+}
+
+// Filename returns a safe filename for a calendar
+func Filename(name string) string {
+	return fmt.Sprintf("%s.ics", strings.NewReplacer(" ", "_", "/", "_", `"`, "").Replace(strings.ToLower(name)))
+}
@@ -0,0 +1,32 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ICal_Calendar(t *testing.T) {
+	start := time.Date(2026, 3, 14, 18, 15, 0, 0, time.UTC)
+	data := string(Calendar("Test Series", []Event{{
+		UID:         "2377-1@irvisualizer",
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Summary:     "Test Series - Spa, Grand Prix; Pits",
+		Description: "Week 1\n" + strings.Repeat("x", 100),
+	}}))
+
+	assert.True(t, strings.HasPrefix(data, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(data, "END:VCALENDAR\r\n"))
+	assert.Contains(t, data, "DTSTART:20260314T181500Z\r\n")
+	assert.Contains(t, data, "DTEND:20260314T191500Z\r\n")
+	assert.Contains(t, data, `SUMMARY:Test Series - Spa\, Grand Prix\; Pits`)
+
+	// content lines must be folded after 75 octets
+	for _, line := range strings.Split(data, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+	assert.Contains(t, data, "DESCRIPTION:Week 1\\n"+strings.Repeat("x", 55)+"\r\n "+strings.Repeat("x", 45))
+}
@@ -9,10 +9,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/JamesClonk/iRcollector/database"
 	"github.com/JamesClonk/iRvisualizer/image/laptime"
 	"github.com/JamesClonk/iRvisualizer/log"
-	"github.com/JamesClonk/iRvisualizer/util"
 	"github.com/gorilla/mux"
 )
 
@@ -40,21 +38,7 @@ func (h *Handler) weeklyLaptimes(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	// was there a reference lap given?
-	var refLap int
-	lap := req.URL.Query().Get("laptime")
-	if strings.Contains(lap, "s") { // 1m23s456ms format
-		refLap = int(util.ParseLaptime(lap))
-	} else { // int milliseconds
-		refLap, _ = strconv.Atoi(lap)
-		refLap = refLap * 10
-	}
-	if refLap < 1 {
-		refLap = 0
-	}
-	refName := req.URL.Query().Get("reference")
-	if len(refName) == 0 {
-		refName = "Reference"
-	}
+	refLap, refName := getReferenceLap(req)
 
 	// was there a colorScheme given?
 	colorScheme := req.URL.Query().Get("colorScheme")
@@ -77,18 +61,34 @@ func (h *Handler) weeklyLaptimes(rw http.ResponseWriter, req *http.Request) {
 	// is there a team given?
 	team := req.URL.Query().Get("team")
 
+	// were there any track or car details requested?
+	enrichment, err := getEnrichment(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants := enrichment.Variants()
+
+	// was there a car class given?
+	carClass, err := getCarClass(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, carClassVariants(carClass)...)
+
 	// do we need to update the image file?
 	// check if file already exists and is up-to-date, serve it immediately if yes
-	if !forceOverwrite && laptime.IsAvailable(colorScheme, seasonID, week, team) {
-		http.ServeFile(rw, req, laptime.Filename(seasonID, week, team))
+	if !forceOverwrite && laptime.IsAvailable(colorScheme, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, laptime.Filename(seasonID, week, team, variants...))
 		return
 	}
 	// lock global mutex
 	laptimeMutex.Lock()
 	defer laptimeMutex.Unlock()
 	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
-	if !forceOverwrite && laptime.IsAvailable(colorScheme, seasonID, week, team) {
-		http.ServeFile(rw, req, laptime.Filename(seasonID, week, team))
+	if !forceOverwrite && laptime.IsAvailable(colorScheme, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, laptime.Filename(seasonID, week, team, variants...))
 		return
 	}
 
@@ -106,6 +106,9 @@ func (h *Handler) weeklyLaptimes(rw http.ResponseWriter, req *http.Request) {
 		raceweek.LastUpdate = time.Now()
 		track.Name = "starting soon..."
 	}
+	if enrichment.CarLogos {
+		enrichment.Cars = h.getRaceWeekCars(raceweek.RaceWeekID)
+	}
 	raceweekLaptimes, err := h.getRaceWeekFastestRaceLaptimes(seasonID, week-1)
 	if err != nil {
 		log.Errorf("laptimes: could not get raceweek fastest race laptimes: %v", err)
@@ -113,6 +116,17 @@ func (h *Handler) weeklyLaptimes(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// which car classes to draw?
+	classes := newCarClasses()
+	if carClass != 0 {
+		classes, err = h.getCarClasses(seasonID, week-1)
+		if err != nil {
+			log.Errorf("laptimes: could not get car classes for season[%d], week[%d]: %v", seasonID, week-1, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
 	// sort by fastest laptimes if not already
 	sort.Slice(raceweekLaptimes, func(i, j int) bool {
 		return raceweekLaptimes[i].Laptime < raceweekLaptimes[j].Laptime
@@ -124,24 +138,34 @@ func (h *Handler) weeklyLaptimes(rw http.ResponseWriter, req *http.Request) {
 		laptimes = append(laptimes, laptime.DataSet{
 			Division: "-",
 			Driver:   refName,
-			Laptime:  database.Laptime(refLap),
+			Laptime:  refLap,
 		})
 	}
-	for division := 1; division <= 5; division++ {
-		for _, rl := range raceweekLaptimes {
-			if rl.Driver.Division == division && rl.Laptime > 100 {
-				laptimes = append(laptimes, laptime.DataSet{
-					Division: fmt.Sprintf("%v", rl.Driver.Division),
-					Driver:   rl.Driver.Name,
-					Laptime:  rl.Laptime,
-					Marked:   isDriverMarked(drivers, rl.Driver.DriverID) || (rl.Driver.Team == team && len(team) > 0),
-				})
-				break
+	for _, class := range classes.selection(carClass) {
+		if class != 0 {
+			laptimes = append(laptimes, laptime.DataSet{
+				Driver:  classes.name(class),
+				Section: true,
+			})
+		}
+		classLaptimes := classes.laptimes(class, raceweekLaptimes)
+		for division := 1; division <= 5; division++ {
+			for _, rl := range classLaptimes {
+				if rl.Driver.Division == division && rl.Laptime > 100 {
+					laptimes = append(laptimes, laptime.DataSet{
+						Division: fmt.Sprintf("%v", rl.Driver.Division),
+						Driver:   rl.Driver.Name,
+						Laptime:  rl.Laptime,
+						Marked:   isDriverMarked(drivers, rl.Driver) || (rl.Driver.Team == team && len(team) > 0),
+					})
+					break
+				}
 			}
 		}
 	}
 
-	l := laptime.New(colorScheme, team, season, raceweek, track, laptimes)
+	l := laptime.New(colorScheme, team, season, raceweek, track, laptimes, variants...)
+	l.Enrichment = enrichment
 	if err := l.Draw(); err != nil {
 		log.Errorf("laptimes: could not create weekly laptime chart: %v", err)
 		h.failure(rw, req, err)
@@ -149,5 +173,5 @@ func (h *Handler) weeklyLaptimes(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	// serve new/updated image
-	http.ServeFile(rw, req, laptime.Filename(seasonID, week, team))
+	http.ServeFile(rw, req, laptime.Filename(seasonID, week, team, variants...))
 }
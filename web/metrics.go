@@ -0,0 +1,396 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image/top"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/rank"
+	"github.com/JamesClonk/iRvisualizer/util"
+)
+
+// maxMetrics is how many metrics fit side by side into a top image
+const maxMetrics = 5
+
+// topSource holds all data a top image can be built from, of a single week or a whole season
+type topSource struct {
+	Summaries         []database.Summary
+	TimeTrialSessions []database.FastestLaptime
+	RaceLaptimes      []database.FastestLaptime
+	Races             map[int]raceCount // number of races per driver, only counted if needed for eligibility
+}
+
+// topOptions are the options of a top image, applied to all of its metrics
+type topOptions struct {
+	TopN        int
+	Marked      func(database.Driver) bool
+	Eligibility eligibility       // minimum participation required in addition to the one of each metric
+	TieBreakers []rank.TieBreaker // to decide the positions of drivers with equal values
+}
+
+// topMetric defines a single column of a top image
+type topMetric struct {
+	Title       string
+	Weekly      bool        // only available for single weeks, there are no laptimes for a whole season
+	Eligibility eligibility // minimum participation to be ranked
+	DataSet     func(source topSource, options topOptions) top.DataSet
+}
+
+// summaryMetric ranks drivers by a single value of their summaries, highest first unless ascending
+type summaryMetric struct {
+	Title       string
+	Icons       string
+	Value       func(s database.Summary) float64
+	Format      func(s database.Summary) string
+	Ascending   bool
+	Eligibility eligibility                                             // optional default minimum participation to be ranked
+	Icon        func(summaries []database.Summary, i int) (string, int) // optional icon and its position for each row
+}
+
+func (m summaryMetric) metric() topMetric {
+	return topMetric{Title: m.Title, Eligibility: m.Eligibility, DataSet: m.dataSet}
+}
+
+func (m summaryMetric) dataSet(source topSource, options topOptions) top.DataSet {
+	data := top.DataSet{
+		Title: m.Title,
+		Icons: m.Icons,
+		Rows:  make([]top.DataSetRow, 0),
+	}
+	summaries := make([]database.Summary, len(source.Summaries))
+	copy(summaries, source.Summaries)
+	// sort by value
+	positions := rankSummaries(summaries, m.Value, m.Ascending, options.TieBreakers)
+	for i := 0; i < options.TopN && i < len(summaries); i++ {
+		row := top.DataSetRow{
+			Position: positions[i],
+			Driver:   summaries[i].Driver.Name,
+			Value:    m.Format(summaries[i]),
+			Marked:   options.Marked(summaries[i].Driver),
+		}
+		if m.Icon != nil {
+			row.Icon, row.IconPosition = m.Icon(summaries, i)
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return data
+}
+
+// laptimeMetric ranks drivers by their fastest laptime, with optional arrows for big gaps to the next driver
+func laptimeMetric(title, icons string, laptimes func(source topSource) []database.FastestLaptime, arrows bool) topMetric {
+	return topMetric{
+		Title:  title,
+		Weekly: true,
+		DataSet: func(source topSource, options topOptions) top.DataSet {
+			data := top.DataSet{
+				Title: title,
+				Icons: icons,
+				Rows:  make([]top.DataSetRow, 0),
+			}
+			// filter by > 100
+			filtered := make([]database.FastestLaptime, 0)
+			for _, lap := range laptimes(source) {
+				if lap.Laptime > 100 {
+					filtered = append(filtered, lap)
+				}
+			}
+			// sort by laptime if not already, with the race summaries for tie-breaking
+			summaries := make(map[int]database.Summary)
+			for _, summary := range source.Summaries {
+				summaries[summary.Driver.DriverID] = summary
+			}
+			entries := make([]rank.Entry, 0, len(filtered))
+			for _, lap := range filtered {
+				entry := summaryEntry(summaries[lap.Driver.DriverID], float64(lap.Laptime))
+				entry.Name = lap.Driver.Name
+				entries = append(entries, entry)
+			}
+			order, positions := rank.Order(entries, true, options.TieBreakers)
+			sorted := make([]database.FastestLaptime, 0, len(filtered))
+			for _, o := range order {
+				sorted = append(sorted, filtered[o])
+			}
+			filtered = sorted
+			for i := 0; i < options.TopN && i < len(filtered); i++ {
+				icon := ""
+				if arrows && i+1 < len(filtered) &&
+					filtered[i+1].Laptime-filtered[i].Laptime > filtered[i].Laptime/333 {
+					icon = "green_arrow"
+				}
+				data.Rows = append(data.Rows, top.DataSetRow{
+					Position:     positions[i],
+					Driver:       filtered[i].Driver.Name,
+					Icon:         icon,
+					IconPosition: 55,
+					Value:        util.ConvertLaptime(filtered[i].Laptime),
+					Marked:       options.Marked(filtered[i].Driver),
+				})
+			}
+			return data
+		},
+	}
+}
+
+// formatInt formats an integer value of a summary
+func formatInt(value func(s database.Summary) int) func(s database.Summary) string {
+	return func(s database.Summary) string {
+		return fmt.Sprintf("%d", value(s))
+	}
+}
+
+// formatGain formats an integer value of a summary, with a "+" for positive values
+func formatGain(value func(s database.Summary) int) func(s database.Summary) string {
+	return func(s database.Summary) string {
+		if value(s) > 0 {
+			return fmt.Sprintf("+%d", value(s))
+		}
+		return fmt.Sprintf("%d", value(s))
+	}
+}
+
+// lapsIcon adds an arrow to drivers with a lot more laps than the next one
+func lapsIcon(summaries []database.Summary, i int) (string, int) {
+	icon := ""
+	if i+1 < len(summaries) &&
+		summaries[i].LapsCompleted-summaries[i+1].LapsCompleted > summaries[i].LapsCompleted/5 {
+		icon = "blue_arrow"
+	}
+	iconPos := 14
+	if summaries[i].LapsCompleted > 99 {
+		iconPos += 7
+	}
+	if summaries[i].LapsCompleted > 999 {
+		iconPos += 7
+	}
+	if summaries[i].LapsCompleted > 9999 { // seasonal totals
+		iconPos += 7
+	}
+	return icon, iconPos
+}
+
+// topMetrics are all metrics a top image can be composed of, by name
+var topMetrics = map[string]topMetric{
+	"champ_points": summaryMetric{
+		Title:  "Highest Championship Points",
+		Icons:  "star",
+		Value:  func(s database.Summary) float64 { return float64(s.HighestChampPoints) },
+		Format: formatInt(func(s database.Summary) int { return s.HighestChampPoints }),
+	}.metric(),
+	"avg_champ_points": summaryMetric{
+		Title:  "Average Championship Points",
+		Icons:  "star",
+		Value:  func(s database.Summary) float64 { return float64(s.AverageChampPoints) },
+		Format: formatInt(func(s database.Summary) int { return s.AverageChampPoints }),
+	}.metric(),
+	"club_points": summaryMetric{
+		Title:  "Total Club Points contributed",
+		Value:  func(s database.Summary) float64 { return float64(s.TotalClubPoints) },
+		Format: formatInt(func(s database.Summary) int { return s.TotalClubPoints }),
+	}.metric(),
+	"wins": summaryMetric{
+		Title:  "Wins",
+		Icons:  "trophy",
+		Value:  func(s database.Summary) float64 { return float64(s.Wins) },
+		Format: formatInt(func(s database.Summary) int { return s.Wins }),
+	}.metric(),
+	"podiums": summaryMetric{
+		Title:  "Podium Positions",
+		Value:  func(s database.Summary) float64 { return float64(s.Podiums) },
+		Format: formatInt(func(s database.Summary) int { return s.Podiums }),
+	}.metric(),
+	"top5": summaryMetric{
+		Title:  "Top5 Hype (Finishing Positions)",
+		Value:  func(s database.Summary) float64 { return float64(s.Top5) },
+		Format: formatInt(func(s database.Summary) int { return s.Top5 }),
+	}.metric(),
+	"poles": summaryMetric{
+		Title:  "Pole Positions",
+		Icons:  "medal",
+		Value:  func(s database.Summary) float64 { return float64(s.Poles) },
+		Format: formatInt(func(s database.Summary) int { return s.Poles }),
+	}.metric(),
+	"positions_gained": summaryMetric{
+		Title:  "Positions gained / Hard Charger",
+		Value:  func(s database.Summary) float64 { return float64(s.TotalPositionsGained) },
+		Format: formatGain(func(s database.Summary) int { return s.TotalPositionsGained }),
+	}.metric(),
+	"races": summaryMetric{
+		Title:  "Most Races (min. 1 Lap)",
+		Icons:  "flag",
+		Value:  func(s database.Summary) float64 { return float64(s.NumberOfRaces) },
+		Format: formatInt(func(s database.Summary) int { return s.NumberOfRaces }),
+	}.metric(),
+	"laps": summaryMetric{
+		Title:  "Laps completed",
+		Value:  func(s database.Summary) float64 { return float64(s.LapsCompleted) },
+		Format: formatInt(func(s database.Summary) int { return s.LapsCompleted }),
+		Icon:   lapsIcon,
+	}.metric(),
+	"laps_lead": summaryMetric{
+		Title:  "Laps led",
+		Value:  func(s database.Summary) float64 { return float64(s.LapsLead) },
+		Format: formatInt(func(s database.Summary) int { return s.LapsLead }),
+	}.metric(),
+	"irating": summaryMetric{
+		Title:  "Total iRating gained",
+		Value:  func(s database.Summary) float64 { return float64(s.TotalIRatingGain) },
+		Format: formatGain(func(s database.Summary) int { return s.TotalIRatingGain }),
+	}.metric(),
+	"highest_irating": summaryMetric{
+		Title:  "Highest iRating gained",
+		Value:  func(s database.Summary) float64 { return float64(s.HighestIRatingGain) },
+		Format: formatGain(func(s database.Summary) int { return s.HighestIRatingGain }),
+	}.metric(),
+	"safety_rating": summaryMetric{
+		Title: "Total Safety Rating gained",
+		Value: func(s database.Summary) float64 { return float64(s.TotalSafetyRatingGain) },
+		Format: func(s database.Summary) string {
+			value := fmt.Sprintf("%.2f", float64(s.TotalSafetyRatingGain)/float64(100))
+			if s.TotalSafetyRatingGain > 0 {
+				value = "+" + value
+			}
+			return value
+		},
+	}.metric(),
+	"incidents": summaryMetric{
+		Title:       "Avg. Incidents per Lap",
+		Icons:       "safety",
+		Value:       func(s database.Summary) float64 { return s.AverageIncidentsPerLap },
+		Format:      func(s database.Summary) string { return fmt.Sprintf("%.3f", s.AverageIncidentsPerLap) },
+		Ascending:   true,
+		Eligibility: eligibility{MinRaces: 3},
+	}.metric(),
+	"tt_laptime": laptimeMetric("Fastest Time Trial Session", "clock", func(source topSource) []database.FastestLaptime {
+		return source.TimeTrialSessions
+	}, false),
+	"race_laptime": laptimeMetric("Fastest Race Lap", "", func(source topSource) []database.FastestLaptime {
+		return source.RaceLaptimes
+	}, true),
+}
+
+// topPresets are the predefined compositions of top images, by name
+var topPresets = map[string][]string{
+	"scores": {"champ_points", "club_points", "podiums"},
+	"racers": {"top5", "positions_gained", "races"},
+	"laps":   {"tt_laptime", "race_laptime", "laps"},
+	"safety": {"irating", "safety_rating", "incidents"},
+}
+
+// configuredTopPresets are all presets added by configuration, their images are stored as custom top images
+var configuredTopPresets = make(map[string]bool)
+
+// topSeasonPresets replace the predefined compositions of top images for a whole season
+var topSeasonPresets = map[string][]string{
+	"racers": {"wins", "top5", "positions_gained", "races"},
+	"laps":   {"laps", "laps_lead"},
+}
+
+// AddTopPresets adds additional top image presets out of a configuration like "podium=wins,podiums,top5;pace=tt_laptime,race_laptime"
+func AddTopPresets(config string) error {
+	for _, preset := range strings.Split(config, ";") {
+		if len(strings.TrimSpace(preset)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(preset, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid top preset [%s], must be like name=metric,metric", preset)
+		}
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if _, ok := topPresets[name]; ok {
+			return fmt.Errorf("top preset [%s] already exists", name)
+		}
+		metrics, err := parseMetrics(parts[1])
+		if err != nil {
+			return err
+		}
+		topPresets[name] = metrics
+		configuredTopPresets[name] = true
+		log.Infof("added top preset [%s]: %s", name, strings.Join(metrics, ", "))
+	}
+	return nil
+}
+
+// getMetrics reads the "metrics" query parameter, a list of 1-5 metric names
+func getMetrics(req *http.Request) ([]string, error) {
+	return parseMetrics(req.URL.Query().Get("metrics"))
+}
+
+// parseMetrics parses and validates a comma separated list of 1-5 metric names
+func parseMetrics(value string) ([]string, error) {
+	metrics := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+		if _, ok := topMetrics[name]; !ok {
+			return nil, fmt.Errorf("invalid metric [%s], must be one of %s", name, strings.Join(topMetricNames(), ", "))
+		}
+		metrics = append(metrics, name)
+	}
+	if len(metrics) < 1 || len(metrics) > maxMetrics {
+		return nil, fmt.Errorf("invalid metrics [%s], must be between 1 and %d metrics", value, maxMetrics)
+	}
+	return metrics, nil
+}
+
+// topMetricNames returns the names of all metrics
+func topMetricNames() []string {
+	names := make([]string, 0, len(topMetrics))
+	for name := range topMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// presetMetrics returns the metrics of a top image preset, for a single week or a whole season if week is <= 0
+func presetMetrics(preset string, week int) ([]string, error) {
+	if metrics, ok := topSeasonPresets[preset]; ok && week <= 0 {
+		return metrics, nil
+	}
+	if metrics, ok := topPresets[preset]; ok {
+		return metrics, nil
+	}
+	return nil, fmt.Errorf("invalid top preset [%s]", preset)
+}
+
+// laptimeMetrics checks if any of the given metrics needs laptimes, and is therefore only available for single weeks
+func laptimeMetrics(metrics []string) bool {
+	for _, name := range metrics {
+		if topMetrics[name].Weekly {
+			return true
+		}
+	}
+	return false
+}
+
+// raceCountMetrics checks if any of the given metrics needs the number of races of each driver for its eligibility
+func raceCountMetrics(metrics []string, options topOptions) bool {
+	for _, name := range metrics {
+		if topMetrics[name].Eligibility.merge(options.Eligibility).needsRaces() {
+			return true
+		}
+	}
+	return false
+}
+
+// metricsData builds the datasets of all given metrics, out of the drivers meeting the minimum participation of each metric
+func metricsData(metrics []string, source topSource, options topOptions) []top.DataSet {
+	data := make([]top.DataSet, 0)
+	for _, name := range metrics {
+		e := topMetrics[name].Eligibility.merge(options.Eligibility)
+		eligible := topSource{
+			Summaries:         eligibleSummaries(e, source.Summaries, source.Races),
+			TimeTrialSessions: eligibleLaptimes(e, source.TimeTrialSessions, source.Summaries, source.Races),
+			RaceLaptimes:      eligibleLaptimes(e, source.RaceLaptimes, source.Summaries, source.Races),
+			Races:             source.Races,
+		}
+		data = append(data, topMetrics[name].DataSet(eligible, options))
+	}
+	return data
+}
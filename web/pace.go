@@ -0,0 +1,190 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JamesClonk/iRvisualizer/image/scatter"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/gorilla/mux"
+)
+
+var paceMutex = &sync.Mutex{}
+
+func (h *Handler) weeklyPace(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("pace: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+	week, err := strconv.Atoi(vars["week"])
+	if err != nil {
+		log.Errorf("pace: could not convert week [%s] to int: %v", vars["week"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if week < 1 || week > 13 {
+		week = 1
+	}
+
+	// what should race pace be compared to? time trial pace or iRating
+	name := "pace"
+	mode := strings.ToLower(req.URL.Query().Get("mode"))
+	switch mode {
+	case "irating":
+		name = "pace_irating"
+	case "tt", "":
+		mode = "tt"
+	default:
+		err := fmt.Errorf("invalid mode [%s], must be either tt or irating", mode)
+		log.Errorf("pace: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value := req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("pace: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// are there any individually marked drivers given?
+	drivers := strings.Split(req.URL.Query().Get("drivers"), ",")
+
+	// is there a team given?
+	team := req.URL.Query().Get("team")
+
+	// were there any track or car details requested?
+	enrichment, err := getEnrichment(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants := enrichment.Variants()
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && scatter.IsAvailable(colorScheme, name, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, scatter.Filename(name, seasonID, week, team, variants...))
+		return
+	}
+	// lock global mutex
+	paceMutex.Lock()
+	defer paceMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && scatter.IsAvailable(colorScheme, name, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, scatter.Filename(name, seasonID, week, team, variants...))
+		return
+	}
+
+	// create/update scatter plot image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("pace: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	raceweek, track, err := h.getRaceWeek(seasonID, week-1)
+	if err != nil {
+		log.Debugf("pace: could not get raceweek for season[%d], week[%d]: %v", seasonID, week-1, err)
+		raceweek.RaceWeek = week - 1
+		raceweek.LastUpdate = time.Now()
+		track.Name = "starting soon..."
+	}
+	if enrichment.CarLogos {
+		enrichment.Cars = h.getRaceWeekCars(raceweek.RaceWeekID)
+	}
+	timeRankings, err := h.getRaceWeekTimeRankings(seasonID, week-1)
+	if err != nil {
+		log.Errorf("pace: could not get raceweek time rankings: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// time rankings do not contain any usable iRating, get it from the latest race results instead
+	iratings := make(map[int]int)
+	if mode == "irating" {
+		results, err := h.getRaceResults(seasonID, week-1)
+		if err != nil {
+			log.Errorf("pace: could not get race results: %v", err)
+			h.failure(rw, req, err)
+			return
+		}
+		for _, result := range results { // results are sorted by session start time
+			if result.IRatingAfter > 0 {
+				iratings[result.Driver.DriverID] = result.IRatingAfter
+			}
+		}
+	}
+
+	title := "TT vs. Race Pace"
+	xAxis := scatter.Axis{Title: "Time Trial Pace", Laptime: true}
+	if mode == "irating" {
+		title = "iRating vs. Race Pace"
+		xAxis = scatter.Axis{Title: "iRating"}
+	}
+	yAxis := scatter.Axis{Title: "Race Pace", Laptime: true}
+
+	data := make([]scatter.DataPoint, 0)
+	for _, tr := range timeRankings {
+		if tr.Race <= 100 {
+			continue
+		}
+		point := scatter.DataPoint{
+			Driver: tr.Driver.Name,
+			Y:      float64(tr.Race),
+			Marked: isDriverMarked(drivers, tr.Driver) || (tr.Driver.Team == team && len(team) > 0),
+		}
+		switch mode {
+		case "irating":
+			irating := iratings[tr.Driver.DriverID]
+			if irating <= 0 {
+				irating = tr.IRating
+			}
+			if irating <= 0 {
+				continue
+			}
+			point.X = float64(irating)
+		default:
+			laptime := tr.TimeTrial
+			if laptime <= 100 {
+				laptime = tr.TimeTrialFastestLap
+			}
+			if laptime <= 100 {
+				continue
+			}
+			point.X = float64(laptime)
+		}
+		data = append(data, point)
+	}
+
+	s := scatter.New(name, title, colorScheme, team, season, raceweek, track, xAxis, yAxis, data)
+	s.Variants = variants
+	s.Enrichment = enrichment
+	if err := s.Draw(); err != nil {
+		log.Errorf("pace: could not create weekly pace scatter plot: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, scatter.Filename(name, seasonID, week, team, variants...))
+}
@@ -0,0 +1,152 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image/positions"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/scoring"
+	"github.com/gorilla/mux"
+)
+
+var positionsMutex = &sync.Mutex{}
+
+func (h *Handler) positions(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("positions: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a topN given?
+	topN := 10
+	value := req.URL.Query().Get("topN")
+	if len(value) > 0 {
+		topN, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("positions: could not convert topN [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if topN < 1 || topN > 15 {
+		topN = 10
+	}
+	variants := make([]string, 0)
+	if topN != 10 {
+		variants = append(variants, fmt.Sprintf("top%d", topN))
+	}
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value = req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("positions: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// are there any individually marked drivers given?
+	drivers := strings.Split(req.URL.Query().Get("drivers"), ",")
+
+	// is there a team given?
+	team := req.URL.Query().Get("team")
+
+	// were there any tie-breakers given?
+	breakers, breakerVariants, err := getTieBreakers(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, breakerVariants...)
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && positions.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, positions.Filename(seasonID, team, variants...))
+		return
+	}
+	// lock global mutex
+	positionsMutex.Lock()
+	defer positionsMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && positions.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, positions.Filename(seasonID, team, variants...))
+		return
+	}
+
+	// create/update positions image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("positions: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	// collect champ & TT points for all weeks, TT points are needed to count weeks the same way the standings do
+	points, err := h.getWeeklyPoints(seasonID, 0, nil, "", scoring.IRacing())
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	// calculate the standings after each week, ranked the same way as the standings
+	weeklyPositions := make([]map[database.Driver]int, 0)
+	var current []standing
+	for week := 1; week <= len(points); week++ {
+		stats, err := h.standingStats(seasonID, points[:week], false, breakers)
+		if err != nil {
+			log.Errorf("positions: could not get tie-breakers for week [%d]: %v", week, err)
+			h.failure(rw, req, err)
+			return
+		}
+		current = rankStandings(champStandings(points[:week], scoring.IRacing()), stats, breakers)
+		standings := make(map[database.Driver]int)
+		for _, s := range current {
+			standings[s.Driver] = s.Position
+		}
+		weeklyPositions = append(weeklyPositions, standings)
+	}
+
+	// take the topN drivers of the current standings
+	data := make([]positions.DataRow, 0)
+	for p, s := range current {
+		if p >= topN {
+			break
+		}
+		row := positions.DataRow{
+			Driver:    s.Driver.Name,
+			Positions: make([]int, 0),
+			Marked:    isDriverMarked(drivers, s.Driver) || (s.Driver.Team == team && len(team) > 0),
+		}
+		for _, standings := range weeklyPositions {
+			row.Positions = append(row.Positions, standings[s.Driver])
+		}
+		data = append(data, row)
+	}
+
+	p := positions.New(colorScheme, team, season, len(points), data, variants...)
+	if err := p.Draw(); err != nil {
+		log.Errorf("positions: could not create season positions: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, positions.Filename(seasonID, team, variants...))
+}
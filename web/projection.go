@@ -0,0 +1,399 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image/projection"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/scoring"
+	"github.com/gorilla/mux"
+)
+
+var projectionMutex = &sync.Mutex{}
+
+// projectedStanding holds the current and the possible final championship points and positions of a driver
+type projectedStanding struct {
+	Driver        database.Driver
+	Position      int
+	Points        int
+	BestPoints    int // final points when scoring as much as in their best week in all remaining weeks
+	WorstPoints   int // final points when not scoring in any remaining week
+	BestPosition  int
+	WorstPosition int
+	Weeks         []float64 // weekly points so far
+}
+
+// finalPoints calculates the championship points of a driver after all weeks of a season,
+// with the given points for each of the remaining weeks
+func finalPoints(weeks []float64, remaining int, value float64, totalWeeks int, rules scoring.Rules) int {
+	values := make([]float64, 0, len(weeks)+remaining)
+	values = append(values, weeks...)
+	participations := len(weeks)
+	for r := 0; r < remaining; r++ {
+		values = append(values, value)
+		if value > 0 {
+			participations++
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] > values[j]
+	})
+	total := rules.Bonus(participations)
+	bestN := rules.CountedWeeks(totalWeeks)
+	for n := 0; n < bestN && n < len(values); n++ {
+		total += values[n]
+	}
+	return int(math.Floor(total))
+}
+
+// projectStandings calculates best and worst case final standings, given the number of remaining weeks
+func projectStandings(weeks []weeklyPoints, remaining int, rules scoring.Rules) []projectedStanding {
+	ccPoints := make(map[database.Driver][]float64)
+	for _, week := range weeks {
+		for driver, value := range week.ChampPoints {
+			ccPoints[driver] = append(ccPoints[driver], value)
+		}
+	}
+	totalWeeks := len(weeks) + remaining
+
+	projections := make([]projectedStanding, 0)
+	for p, s := range champStandings(weeks, rules) {
+		var best float64
+		for _, value := range ccPoints[s.Driver] {
+			best = math.Max(best, value)
+		}
+		projections = append(projections, projectedStanding{
+			Driver:      s.Driver,
+			Position:    p + 1,
+			Points:      s.Points,
+			BestPoints:  finalPoints(ccPoints[s.Driver], remaining, best, totalWeeks, rules),
+			WorstPoints: finalPoints(ccPoints[s.Driver], remaining, 0, totalWeeks, rules),
+			Weeks:       ccPoints[s.Driver],
+		})
+	}
+
+	// best position if everybody else scores nothing anymore, worst position if everybody else scores their best
+	for p := range projections {
+		projections[p].BestPosition = 1
+		projections[p].WorstPosition = 1
+		for o, other := range projections {
+			if o == p {
+				continue
+			}
+			if other.WorstPoints > projections[p].BestPoints {
+				projections[p].BestPosition++
+			}
+			if other.BestPoints >= projections[p].WorstPoints {
+				projections[p].WorstPosition++
+			}
+		}
+	}
+	return projections
+}
+
+// requiredPoints calculates the weekly points a driver needs in all remaining weeks to finish ahead of a rival,
+// if the rival keeps scoring rivalValue per week. Returns false if it's not possible with up to maxValue per week
+func requiredPoints(driver, rival projectedStanding, remaining, totalWeeks int, rivalValue, maxValue float64, rules scoring.Rules) (int, bool) {
+	rivalPoints := finalPoints(rival.Weeks, remaining, rivalValue, totalWeeks, rules)
+	for value := 0; value <= int(math.Ceil(maxValue)); value++ {
+		if finalPoints(driver.Weeks, remaining, float64(value), totalWeeks, rules) > rivalPoints {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// average returns the average of all values
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// parseProjectionParameters reads seasonID from the request path and the total number of weeks, scoring rules and car class from the query
+func parseProjectionParameters(req *http.Request) (int, int, scoring.Rules, []string, int, error) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("projection: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		return 0, 0, nil, nil, 0, err
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	// how many weeks does the season have?
+	totalWeeks := 12
+	value := req.URL.Query().Get("weeks")
+	if len(value) > 0 {
+		totalWeeks, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("projection: could not convert weeks [%s] to int: %v", value, err)
+			return 0, 0, nil, nil, 0, err
+		}
+	}
+	if totalWeeks < 1 || totalWeeks > 13 {
+		totalWeeks = 12
+	}
+
+	// were there any scoring rules given?
+	rules, variants, err := getRules(req, "iracing")
+	if err != nil {
+		return 0, 0, nil, nil, 0, err
+	}
+	if totalWeeks != 12 {
+		variants = append(variants, fmt.Sprintf("weeks_%d", totalWeeks))
+	}
+
+	// was there a car class given?
+	carClass, err := getCarClass(req)
+	if err != nil {
+		return 0, 0, nil, nil, 0, err
+	}
+	if carClass == carClassAll {
+		return 0, 0, nil, nil, 0, fmt.Errorf("carClass [all] is not supported for projections, use a car class ID")
+	}
+	variants = append(variants, carClassVariants(carClass)...)
+	return seasonID, totalWeeks, rules, variants, carClass, nil
+}
+
+func (h *Handler) seasonProjection(rw http.ResponseWriter, req *http.Request) {
+	seasonID, totalWeeks, rules, variants, carClass, err := parseProjectionParameters(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a topN given?
+	topN := 15
+	value := req.URL.Query().Get("topN")
+	if len(value) > 0 {
+		topN, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("projection: could not convert topN [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if topN < 1 || topN > 25 {
+		topN = 15
+	}
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value = req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("projection: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// are there any individually marked drivers given?
+	drivers := strings.Split(req.URL.Query().Get("drivers"), ",")
+
+	// is there a team given?
+	team := req.URL.Query().Get("team")
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && projection.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, projection.Filename(seasonID, team, variants...))
+		return
+	}
+	// lock global mutex
+	projectionMutex.Lock()
+	defer projectionMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && projection.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, projection.Filename(seasonID, team, variants...))
+		return
+	}
+
+	// create/update projection image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("projection: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	points, err := h.getWeeklyPoints(seasonID, carClass, nil, "", rules)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	remaining := totalWeeks - len(points)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	data := make([]projection.DataRow, 0)
+	for p, s := range projectStandings(points, remaining, rules) {
+		if p >= topN {
+			break
+		}
+		data = append(data, projection.DataRow{
+			Driver:        s.Driver.Name,
+			Points:        s.Points,
+			BestPoints:    s.BestPoints,
+			WorstPoints:   s.WorstPoints,
+			BestPosition:  s.BestPosition,
+			WorstPosition: s.WorstPosition,
+			Marked:        isDriverMarked(drivers, s.Driver) || (s.Driver.Team == team && len(team) > 0),
+		})
+	}
+
+	p := projection.New(colorScheme, team, season, len(points), remaining, data, variants...)
+	if err := p.Draw(); err != nil {
+		log.Errorf("projection: could not create championship projection: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, projection.Filename(seasonID, team, variants...))
+}
+
+type projectionJson struct {
+	SeasonID         int                    `json:"season_id"`
+	Rules            string                 `json:"rules"`
+	Weeks            int                    `json:"weeks"`
+	RemainingWeeks   int                    `json:"remaining_weeks"`
+	Driver           projectedStandingJson  `json:"driver"`
+	TargetPosition   int                    `json:"target_position"`
+	Rival            *projectedStandingJson `json:"rival,omitempty"`
+	RequiredMinimum  *int                   `json:"required_minimum"`  // weekly points needed if the rival doesn't score anymore
+	RequiredExpected *int                   `json:"required_expected"` // weekly points needed if the rival keeps their average
+	MaximumWeekly    int                    `json:"maximum_weekly"`    // highest weekly points of anyone so far
+}
+
+type projectedStandingJson struct {
+	DriverID      int    `json:"driver_id"`
+	Name          string `json:"name"`
+	Position      int    `json:"position"`
+	Points        int    `json:"points"`
+	BestPoints    int    `json:"best_points"`
+	WorstPoints   int    `json:"worst_points"`
+	BestPosition  int    `json:"best_position"`
+	WorstPosition int    `json:"worst_position"`
+}
+
+func projectedStandingToJson(s projectedStanding) projectedStandingJson {
+	return projectedStandingJson{
+		DriverID:      s.Driver.DriverID,
+		Name:          s.Driver.Name,
+		Position:      s.Position,
+		Points:        s.Points,
+		BestPoints:    s.BestPoints,
+		WorstPoints:   s.WorstPoints,
+		BestPosition:  s.BestPosition,
+		WorstPosition: s.WorstPosition,
+	}
+}
+
+func (h *Handler) seasonProjectionJson(rw http.ResponseWriter, req *http.Request) {
+	seasonID, totalWeeks, rules, _, carClass, err := parseProjectionParameters(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	// which driver?
+	driverID, err := strconv.Atoi(req.URL.Query().Get("driver"))
+	if err != nil {
+		log.Errorf("projection: could not convert driver [%s] to int: %v", req.URL.Query().Get("driver"), err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// which target position?
+	target := 1
+	value := req.URL.Query().Get("target")
+	if len(value) > 0 {
+		target, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("projection: could not convert target [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if target < 1 {
+		target = 1
+	}
+
+	points, err := h.getWeeklyPoints(seasonID, carClass, nil, "", rules)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	remaining := totalWeeks - len(points)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	projections := projectStandings(points, remaining, rules)
+	var maxValue float64
+	driver := -1
+	for p, s := range projections {
+		for _, value := range s.Weeks {
+			maxValue = math.Max(maxValue, value)
+		}
+		if s.Driver.DriverID == driverID {
+			driver = p
+		}
+	}
+	if driver < 0 {
+		h.failure(rw, req, fmt.Errorf("driver [%d] has no championship points in season [%d]", driverID, seasonID))
+		return
+	}
+
+	result := projectionJson{
+		SeasonID:       seasonID,
+		Rules:          rules.Name(),
+		Weeks:          len(points),
+		RemainingWeeks: remaining,
+		Driver:         projectedStandingToJson(projections[driver]),
+		TargetPosition: target,
+		MaximumWeekly:  int(math.Floor(maxValue)),
+	}
+
+	// the rival is whoever holds the target position, or the driver right behind if the target is already reached
+	rival := target - 1
+	if driver <= rival {
+		rival++
+	}
+	if rival < len(projections) {
+		rj := projectedStandingToJson(projections[rival])
+		result.Rival = &rj
+		if minimum, ok := requiredPoints(projections[driver], projections[rival], remaining, len(points)+remaining, 0, maxValue, rules); ok {
+			result.RequiredMinimum = &minimum
+		}
+		rivalAverage := average(projections[rival].Weeks)
+		if expected, ok := requiredPoints(projections[driver], projections[rival], remaining, len(points)+remaining, rivalAverage, maxValue, rules); ok {
+			result.RequiredExpected = &expected
+		}
+	} else {
+		// nobody to beat
+		zero := 0
+		result.RequiredMinimum = &zero
+		result.RequiredExpected = &zero
+	}
+	h.writeJson(rw, req, result)
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/scoring"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	driverA = database.Driver{DriverID: 1, Name: "Driver A"}
+	driverB = database.Driver{DriverID: 2, Name: "Driver B"}
+	driverC = database.Driver{DriverID: 3, Name: "Driver C"}
+)
+
+func projectionWeeks() []weeklyPoints {
+	return []weeklyPoints{
+		{Week: 0, ChampPoints: map[database.Driver]float64{driverA: 100, driverB: 100, driverC: 50}},
+		{Week: 1, ChampPoints: map[database.Driver]float64{driverA: 50, driverB: 50, driverC: 40}},
+	}
+}
+
+func Test_Projection_FinalPoints(t *testing.T) {
+	tests := []struct {
+		weeks      []float64
+		remaining  int
+		value      float64
+		totalWeeks int
+		expected   int
+	}{
+		{[]float64{100, 50}, 0, 0, 2, 150},         // nothing remaining, no dropweeks yet
+		{[]float64{100, 50}, 1, 80, 3, 180},        // 1 dropweek out of 3
+		{[]float64{100, 50}, 1, 0, 3, 150},         // not scoring anymore
+		{[]float64{100, 50, 70}, 3, 90, 6, 370},    // best 4 out of 6
+		{[]float64{}, 2, 10.5, 2, 21},              // rounded down
+		{[]float64{100, 50}, 1, 100, 3, 200},       // best week repeated
+		{[]float64{60, 50, 40, 30}, 0, 0, 4, 150},  // worst week dropped
+		{[]float64{60, 50, 40, 30}, 2, 70, 6, 250}, // 2 dropweeks out of 6
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, finalPoints(test.weeks, test.remaining, test.value, test.totalWeeks, scoring.IRacing()))
+	}
+}
+
+func Test_Projection_ProjectStandings(t *testing.T) {
+	tests := []struct {
+		remaining int
+		driver    database.Driver
+		position  int
+		points    int
+		best      int
+		worst     int
+		bestPos   int
+		worstPos  int
+	}{
+		// nothing remaining, A and B are tied and can end up in each others position
+		{0, driverA, 1, 150, 150, 150, 1, 2},
+		{0, driverB, 2, 150, 150, 150, 1, 2},
+		{0, driverC, 3, 90, 90, 90, 3, 3},
+		// 1 week remaining, with 1 dropweek out of 3
+		{1, driverA, 1, 150, 200, 150, 1, 2},
+		{1, driverB, 2, 150, 200, 150, 1, 2},
+		{1, driverC, 3, 90, 100, 90, 3, 3},
+	}
+
+	for _, test := range tests {
+		projections := projectStandings(projectionWeeks(), test.remaining, scoring.IRacing())
+		assert.Len(t, projections, 3)
+		found := false
+		for _, p := range projections {
+			if p.Driver != test.driver {
+				continue
+			}
+			found = true
+			assert.Equal(t, test.position, p.Position, test.driver.Name)
+			assert.Equal(t, test.points, p.Points, test.driver.Name)
+			assert.Equal(t, test.best, p.BestPoints, test.driver.Name)
+			assert.Equal(t, test.worst, p.WorstPoints, test.driver.Name)
+			assert.Equal(t, test.bestPos, p.BestPosition, test.driver.Name)
+			assert.Equal(t, test.worstPos, p.WorstPosition, test.driver.Name)
+		}
+		assert.True(t, found, test.driver.Name)
+	}
+}
+
+func Test_Projection_RequiredPoints(t *testing.T) {
+	projections := make(map[database.Driver]projectedStanding)
+	for _, p := range projectStandings(projectionWeeks(), 1, scoring.IRacing()) {
+		projections[p.Driver] = p
+	}
+
+	tests := []struct {
+		driver     database.Driver
+		rival      database.Driver
+		remaining  int
+		rivalValue float64
+		maxValue   float64
+		expected   int
+		possible   bool
+	}{
+		{driverA, driverC, 1, 0, 100, 0, true},    // target already reached
+		{driverA, driverC, 1, 40, 100, 0, true},   // still ahead if the rival keeps their average
+		{driverA, driverC, 1, 100, 100, 51, true}, // rival scores the maximum
+		{driverB, driverA, 1, 0, 100, 51, true},   // needs to break the tie
+		{driverB, driverA, 1, 100, 100, 0, false}, // unreachable if the rival scores the maximum
+		{driverC, driverA, 1, 0, 100, 0, false},   // unreachable
+		{driverC, driverA, 1, 0, 200, 101, true},  // reachable with more points per week
+		{driverB, driverA, 0, 0, 100, 0, false},   // nothing remaining, tie can't be broken
+		{driverA, driverB, 0, 0, 100, 0, false},   // nothing remaining, even the leader is only tied
+	}
+
+	for _, test := range tests {
+		value, ok := requiredPoints(projections[test.driver], projections[test.rival], test.remaining, 2+test.remaining, test.rivalValue, test.maxValue, scoring.IRacing())
+		assert.Equal(t, test.possible, ok, "%s vs. %s", test.driver.Name, test.rival.Name)
+		assert.Equal(t, test.expected, value, "%s vs. %s", test.driver.Name, test.rival.Name)
+	}
+}
@@ -1,16 +1,13 @@
 package web
 
 import (
+	"encoding/json"
 	"fmt"
-	"math"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/JamesClonk/iRcollector/database"
-	"github.com/JamesClonk/iRvisualizer/image/oval_ranking"
 	"github.com/JamesClonk/iRvisualizer/image/ranking"
 	"github.com/JamesClonk/iRvisualizer/log"
 	"github.com/gorilla/mux"
@@ -51,18 +48,73 @@ func (h *Handler) ranking(rw http.ResponseWriter, req *http.Request) {
 	// is there a team given?
 	team := req.URL.Query().Get("team")
 
+	// was there a car class given?
+	carClass, err := getCarClass(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants := carClassVariants(carClass)
+
+	// was there a track category given?
+	category, categoryVariants, err := getCategory(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, categoryVariants...)
+
+	// were there any scoring rules given?
+	rules, rulesVariants, err := getRules(req, defaultRules(category))
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, rulesVariants...)
+
+	// were there any tie-breakers given?
+	breakers, breakerVariants, err := getTieBreakers(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, breakerVariants...)
+
+	// was there a division given?
+	division, err := getDivision(req, carClass)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, divisionVariants(division)...)
+
+	// should the time trial standings be drawn?
+	timeTrial := true
+	value = req.URL.Query().Get("timeTrial")
+	if len(value) > 0 {
+		timeTrial, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("could not convert timeTrial [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if !timeTrial {
+		variants = append(variants, "no_tt")
+	}
+
 	// do we need to update the image file?
 	// check if file already exists and is up-to-date, serve it immediately if yes
-	if !forceOverwrite && ranking.IsAvailable(colorScheme, seasonID, team) {
-		http.ServeFile(rw, req, ranking.Filename(seasonID, team))
+	if !forceOverwrite && ranking.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, ranking.Filename(seasonID, team, variants...))
 		return
 	}
 	// lock global mutex
 	rankingMutex.Lock()
 	defer rankingMutex.Unlock()
 	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
-	if !forceOverwrite && ranking.IsAvailable(colorScheme, seasonID, team) {
-		http.ServeFile(rw, req, ranking.Filename(seasonID, team))
+	if !forceOverwrite && ranking.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, ranking.Filename(seasonID, team, variants...))
 		return
 	}
 
@@ -73,116 +125,92 @@ func (h *Handler) ranking(rw http.ResponseWriter, req *http.Request) {
 		h.failure(rw, req, err)
 		return
 	}
-	// collect champ & TT points for all weeks
-	var weeks int
-	ccPoints := make(map[database.Driver][]float64)
-	ttPoints := make(map[database.Driver][]int)
-	for week := 0; week < 13; week++ { // allow for leap seasons with 13 official weeks, like 2020S3
-		weeklyCcPoints, err := h.getChampPoints(seasonID, week)
+	// which car classes to draw? collected once for all weeks and classes
+	classes := newCarClasses()
+	var weeklyClasses []carClasses
+	if carClass != 0 {
+		weeklyClasses, err = h.getWeeklyCarClasses(seasonID)
 		if err != nil {
-			log.Errorf("could not get championship points for week [%d]: %v", week+1, err)
+			log.Errorf("could not get car classes: %v", err)
 			h.failure(rw, req, err)
 			return
 		}
-		weeklyTtResults, err := h.getTTStandings(seasonID, week)
+		classes = seasonCarClasses(weeklyClasses)
+	}
+
+	var bestN, weeks int
+	var sideBySide bool
+	sections := make([]ranking.Section, 0)
+	for _, class := range classes.selection(carClass) {
+		// collect champ & TT points for all weeks
+		points, err := h.getWeeklyPoints(seasonID, class, weeklyClasses, category, rules)
 		if err != nil {
-			log.Errorf("could not get TT results for week [%d]: %v", week+1, err)
 			h.failure(rw, req, err)
 			return
 		}
+		weeks = len(points)
+		bestN = rules.CountedWeeks(weeks) // how many weeks to count so far? (removes dropweeks)
 
-		// do we have data for this week?
-		if len(weeklyCcPoints) > 0 || len(weeklyTtResults) > 0 {
-			weeks++
+		champ := champStandings(points, rules)
+		tt := ttStandings(points, rules)
+		champStats, err := h.standingStats(seasonID, points, false, breakers)
+		if err != nil {
+			log.Errorf("could not get championship tie-breakers: %v", err)
+			h.failure(rw, req, err)
+			return
 		}
-
-		// collect champpoints for all drivers
-		if len(weeklyCcPoints) > 0 {
-			drivers := make(map[database.Driver][]int)
-			for _, p := range weeklyCcPoints {
-				if _, ok := drivers[p.Driver]; !ok {
-					drivers[p.Driver] = make([]int, 0)
-				}
-				drivers[p.Driver] = append(drivers[p.Driver], p.ChampPoints)
+		ttStats, err := h.standingStats(seasonID, points, true, breakers)
+		if err != nil {
+			log.Errorf("could not get time trial tie-breakers: %v", err)
+			h.failure(rw, req, err)
+			return
+		}
+		divisions := divisionSelection(division, standingDrivers(champ, tt))
+		sideBySide = division == divisionAll && divisions[0] != 0
+		for _, div := range divisions {
+			section := ranking.Section{
+				ChampData: make([]ranking.DataRow, 0),
+				TTData:    make([]ranking.DataRow, 0),
 			}
-			// figure out points for each driver this week
-			for driver, values := range drivers {
-				resultCount := int(math.Ceil(float64(len(values)) / 4))
-				var result float64
-				for i := 0; i < resultCount; i++ {
-					result += float64(values[i])
-				}
-				// final result / average
-				if _, ok := ccPoints[driver]; !ok {
-					ccPoints[driver] = make([]float64, 0)
-				}
-				ccPoints[driver] = append(ccPoints[driver], (result / float64(resultCount)))
+			titles := make([]string, 0)
+			if class != 0 {
+				titles = append(titles, classes.name(class))
 			}
-		}
-
-		// collect TT points for all drivers
-		if len(weeklyTtResults) > 0 {
-			for _, tt := range weeklyTtResults {
-				if _, ok := ttPoints[tt.Driver]; !ok {
-					ttPoints[tt.Driver] = make([]int, 0)
-				}
-				ttPoints[tt.Driver] = append(ttPoints[tt.Driver], tt.Points)
+			if div != 0 {
+				titles = append(titles, divisionName(div))
+			}
+			section.Title = strings.Join(titles, " - ")
+			// total bestN values
+			for _, s := range rankStandings(divisionStandings(div, champ), champStats, breakers) {
+				section.ChampData = append(section.ChampData, ranking.DataRow{
+					Position: s.Position,
+					Driver:   s.Driver.Name,
+					Value:    fmt.Sprintf("%d", s.Points),
+					Marked:   isDriverMarked(drivers, s.Driver) || (s.Driver.Team == team && len(team) > 0),
+				})
+			}
+			for _, s := range rankStandings(divisionStandings(div, tt), ttStats, breakers) {
+				section.TTData = append(section.TTData, ranking.DataRow{
+					Position: s.Position,
+					Driver:   s.Driver.Name,
+					Value:    fmt.Sprintf("%d", s.Points),
+					Marked:   isDriverMarked(drivers, s.Driver) || (s.Driver.Team == team && len(team) > 0),
+				})
 			}
+			sections = append(sections, section)
 		}
 	}
-	bestN := weeks - int(math.Floor(float64(weeks)/3)) // how many weeks to count so far? (removes dropweeks)
 
-	// total bestN values
-	champData := make([]ranking.DataRow, 0)
-	for driver, values := range ccPoints {
-		sort.Slice(values, func(i, j int) bool {
-			return values[i] > values[j]
-		})
-		var total float64
-		for n := 0; n < bestN && n < len(values); n++ {
-			total += values[n]
-		}
-		champData = append(champData, ranking.DataRow{
-			Driver: driver.Name,
-			Value:  fmt.Sprintf("%d", int(math.Floor(total))),
-			Marked: isDriverMarked(drivers, driver.DriverID) || (driver.Team == team && len(team) > 0),
-		})
+	r := ranking.New(colorScheme, team, season, sections, variants...)
+	if sideBySide {
+		r = ranking.NewSideBySide(colorScheme, team, season, sections, variants...)
 	}
-	// sort by values
-	sort.Slice(champData, func(i, j int) bool {
-		return champData[i].Driver < champData[j].Driver
-	})
-	sort.Slice(champData, func(i, j int) bool {
-		a, _ := strconv.Atoi(champData[i].Value)
-		b, _ := strconv.Atoi(champData[j].Value)
-		return a > b
-	})
-	ttData := make([]ranking.DataRow, 0)
-	for driver, values := range ttPoints {
-		sort.Slice(values, func(i, j int) bool {
-			return values[i] > values[j]
-		})
-		var total int
-		for n := 0; n < bestN && n < len(values); n++ {
-			total += values[n]
-		}
-		ttData = append(ttData, ranking.DataRow{
-			Driver: driver.Name,
-			Value:  fmt.Sprintf("%d", total),
-			Marked: isDriverMarked(drivers, driver.DriverID) || (driver.Team == team && len(team) > 0),
-		})
+	if !timeTrial {
+		r.WithoutTimeTrial()
+	}
+	if len(category) > 0 {
+		r.Category = categoryName(category)
 	}
-	// sort by values
-	sort.Slice(ttData, func(i, j int) bool {
-		return ttData[i].Driver < ttData[j].Driver
-	})
-	sort.Slice(ttData, func(i, j int) bool {
-		a, _ := strconv.Atoi(ttData[i].Value)
-		b, _ := strconv.Atoi(ttData[j].Value)
-		return a > b
-	})
-
-	r := ranking.New(colorScheme, team, season, champData, ttData)
 	if err := r.Draw(bestN, weeks); err != nil {
 		log.Errorf("could not create season ranking: %v", err)
 		h.failure(rw, req, err)
@@ -190,14 +218,45 @@ func (h *Handler) ranking(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	// serve new/updated image
-	http.ServeFile(rw, req, ranking.Filename(seasonID, team))
+	http.ServeFile(rw, req, ranking.Filename(seasonID, team, variants...))
 }
 
+// ovalRanking is an alias of ranking, for the standings of all weeks with oval tracks and without time trial
 func (h *Handler) ovalRanking(rw http.ResponseWriter, req *http.Request) {
-	vars := mux.Vars(req)
-	seasonID, err := strconv.Atoi(vars["seasonID"])
+	h.ranking(rw, withDefault(withCategory(req, "oval"), "timeTrial", "false"))
+}
+
+// defaultRules returns the default scoring rules preset, without dropweeks if less than 4 weeks of a track category
+func defaultRules(category string) string {
+	if len(category) > 0 {
+		return "few_weeks"
+	}
+	return "iracing"
+}
+
+type standingsJson struct {
+	SeasonID     int            `json:"season_id"`
+	Rules        string         `json:"rules"`
+	Category     string         `json:"category,omitempty"`
+	CarClassID   int            `json:"car_class_id,omitempty"`
+	Weeks        int            `json:"weeks"`
+	CountedWeeks int            `json:"counted_weeks"`
+	Championship []standingJson `json:"championship"`
+	TimeTrial    []standingJson `json:"time_trial,omitempty"`
+}
+
+type standingJson struct {
+	Position int    `json:"position"`
+	DriverID int    `json:"driver_id"`
+	Name     string `json:"name"`
+	Team     string `json:"team,omitempty"`
+	Points   int    `json:"points"`
+}
+
+func (h *Handler) rankingJson(rw http.ResponseWriter, req *http.Request) {
+	seasonID, err := strconv.Atoi(mux.Vars(req)["seasonID"])
 	if err != nil {
-		log.Errorf("could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		log.Errorf("could not convert seasonID [%s] to int: %v", mux.Vars(req)["seasonID"], err)
 		h.failure(rw, req, err)
 		return
 	}
@@ -205,126 +264,93 @@ func (h *Handler) ovalRanking(rw http.ResponseWriter, req *http.Request) {
 		seasonID = 2377
 	}
 
-	// was there a colorScheme given?
-	colorScheme := req.URL.Query().Get("colorScheme")
-
-	// was there a forceOverwrite given?
-	forceOverwrite := false
-	value := req.URL.Query().Get("forceOverwrite")
-	if len(value) > 0 {
-		forceOverwrite, err = strconv.ParseBool(value)
-		if err != nil {
-			log.Errorf("could not convert forceOverwrite [%s] to bool: %v", value, err)
-			h.failure(rw, req, err)
-			return
-		}
+	// was there a car class given?
+	carClass, err := getCarClass(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	if carClass == carClassAll {
+		h.failure(rw, req, fmt.Errorf("carClass [all] is not supported for JSON output, use a car class ID"))
+		return
 	}
 
-	// are there any individually marked drivers given?
-	drivers := strings.Split(req.URL.Query().Get("drivers"), ",")
-
-	// is there a team given?
-	team := req.URL.Query().Get("team")
-
-	// do we need to update the image file?
-	// check if file already exists and is up-to-date, serve it immediately if yes
-	if !forceOverwrite && oval_ranking.IsAvailable(colorScheme, seasonID, team) {
-		http.ServeFile(rw, req, oval_ranking.Filename(seasonID, team))
+	// was there a track category given?
+	category, _, err := getCategory(req)
+	if err != nil {
+		h.failure(rw, req, err)
 		return
 	}
-	// lock global mutex
-	rankingMutex.Lock()
-	defer rankingMutex.Unlock()
-	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
-	if !forceOverwrite && oval_ranking.IsAvailable(colorScheme, seasonID, team) {
-		http.ServeFile(rw, req, oval_ranking.Filename(seasonID, team))
+
+	// were there any scoring rules given?
+	rules, _, err := getRules(req, defaultRules(category))
+	if err != nil {
+		h.failure(rw, req, err)
 		return
 	}
 
-	// create/update oval ranking image
-	season, err := h.getSeason(seasonID)
+	// were there any tie-breakers given?
+	breakers, _, err := getTieBreakers(req)
 	if err != nil {
-		log.Errorf("could not get season: %v", err)
 		h.failure(rw, req, err)
 		return
 	}
-	// collect champ points for all weeks with oval tracks
-	var weeks int
-	ccPoints := make(map[database.Driver][]float64)
-	for week := 0; week < 13; week++ { // allow for leap seasons with 13 official weeks, like 2020S3
-		weeklyCcPoints, err := h.getChampPointsForOvals(seasonID, week)
-		if err != nil {
-			log.Errorf("could not get championship points for week [%d]: %v", week+1, err)
-			h.failure(rw, req, err)
-			return
-		}
-		// do we have data for this week?
-		if len(weeklyCcPoints) > 0 {
-			weeks++
-		}
 
-		// collect champpoints for all drivers
-		if len(weeklyCcPoints) > 0 {
-			drivers := make(map[database.Driver][]int)
-			for _, p := range weeklyCcPoints {
-				if _, ok := drivers[p.Driver]; !ok {
-					drivers[p.Driver] = make([]int, 0)
-				}
-				drivers[p.Driver] = append(drivers[p.Driver], p.ChampPoints)
-			}
-			// figure out points for each driver this week
-			for driver, values := range drivers {
-				resultCount := int(math.Ceil(float64(len(values)) / 4))
-				var result float64
-				for i := 0; i < resultCount; i++ {
-					result += float64(values[i])
-				}
-				// final result / average
-				if _, ok := ccPoints[driver]; !ok {
-					ccPoints[driver] = make([]float64, 0)
-				}
-				ccPoints[driver] = append(ccPoints[driver], (result / float64(resultCount)))
-			}
-		}
+	points, err := h.getWeeklyPoints(seasonID, carClass, nil, category, rules)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
 	}
-	bestN := weeks // how many weeks to count so far?
-	if weeks > 3 { // no dropweeks for oval ranking if less than 4 weeks
-		bestN = weeks - int(math.Floor(float64(weeks)/3)) // how many weeks to count so far? (removes dropweeks)
+	champStats, err := h.standingStats(seasonID, points, false, breakers)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
 	}
+	ttStats, err := h.standingStats(seasonID, points, true, breakers)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	result := standingsJson{
+		SeasonID:     seasonID,
+		Rules:        rules.Name(),
+		Category:     category,
+		CarClassID:   carClass,
+		Weeks:        len(points),
+		CountedWeeks: rules.CountedWeeks(len(points)),
+		Championship: standingsToJson(rankStandings(champStandings(points, rules), champStats, breakers)),
+		TimeTrial:    standingsToJson(rankStandings(ttStandings(points, rules), ttStats, breakers)),
+	}
+	h.writeJson(rw, req, result)
+}
 
-	// total bestN values
-	champData := make([]oval_ranking.DataRow, 0)
-	for driver, values := range ccPoints {
-		sort.Slice(values, func(i, j int) bool {
-			return values[i] > values[j]
-		})
-		var total float64
-		for n := 0; n < bestN && n < len(values); n++ {
-			total += values[n]
-		}
-		champData = append(champData, oval_ranking.DataRow{
-			Driver: driver.Name,
-			Value:  fmt.Sprintf("%d", int(math.Floor(total))),
-			Marked: isDriverMarked(drivers, driver.DriverID) || (driver.Team == team && len(team) > 0),
+// ovalRankingJson is an alias of rankingJson, for the standings of all weeks with oval tracks
+func (h *Handler) ovalRankingJson(rw http.ResponseWriter, req *http.Request) {
+	h.rankingJson(rw, withCategory(req, "oval"))
+}
+
+func standingsToJson(standings []standing) []standingJson {
+	result := make([]standingJson, 0)
+	for _, s := range standings {
+		result = append(result, standingJson{
+			Position: s.Position,
+			DriverID: s.Driver.DriverID,
+			Name:     s.Driver.Name,
+			Team:     s.Driver.Team,
+			Points:   s.Points,
 		})
 	}
-	// sort by values
-	sort.Slice(champData, func(i, j int) bool {
-		return champData[i].Driver < champData[j].Driver
-	})
-	sort.Slice(champData, func(i, j int) bool {
-		a, _ := strconv.Atoi(champData[i].Value)
-		b, _ := strconv.Atoi(champData[j].Value)
-		return a > b
-	})
-
-	r := oval_ranking.New(colorScheme, team, season, champData)
-	if err := r.Draw(bestN, weeks); err != nil {
-		log.Errorf("could not create season oval ranking: %v", err)
+	return result
+}
+
+func (h *Handler) writeJson(rw http.ResponseWriter, req *http.Request, result interface{}) {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Errorf("could not marshal JSON output: %v", err)
 		h.failure(rw, req, err)
 		return
 	}
-
-	// serve new/updated image
-	http.ServeFile(rw, req, oval_ranking.Filename(seasonID, team))
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(200)
+	_, _ = rw.Write(data)
 }
@@ -0,0 +1,155 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/rank"
+)
+
+// defaultTieBreakers are used for all ranked lists without a "tieBreakers" query parameter, set by configuration
+var defaultTieBreakers = []rank.TieBreaker{}
+
+// SetTieBreakers sets the default tie-breakers out of a configuration like "wins,recent,incidents"
+func SetTieBreakers(config string) error {
+	breakers, err := rank.Parse(config)
+	if err != nil {
+		return err
+	}
+	defaultTieBreakers = breakers
+	return nil
+}
+
+// getTieBreakers reads the optional "tieBreakers" query parameter, a list of tie-breakers, and returns its image file variants.
+// Without the query parameter the default tie-breakers are used, there are no variants only if there are no tie-breakers at all
+func getTieBreakers(req *http.Request) ([]rank.TieBreaker, []string, error) {
+	breakers := defaultTieBreakers
+	value := req.URL.Query().Get("tieBreakers")
+	if len(value) > 0 {
+		var err error
+		breakers, err = rank.Parse(value)
+		if err != nil {
+			log.Errorf("could not get tie-breakers [%s]: %v", value, err)
+			return nil, nil, err
+		}
+	}
+	if len(breakers) == 0 {
+		return breakers, []string{}, nil
+	}
+	names := make([]string, 0)
+	for _, breaker := range breakers {
+		names = append(names, string(breaker))
+	}
+	return breakers, []string{"tiebreakers_" + strings.Join(names, "_")}, nil
+}
+
+// needsSummaries checks if any of the tie-breakers needs the race summaries of each driver
+func needsSummaries(breakers []rank.TieBreaker) bool {
+	for _, breaker := range breakers {
+		if breaker == rank.Wins || breaker == rank.Incidents {
+			return true
+		}
+	}
+	return false
+}
+
+// summaryEntry returns the rank entry of a summary, with its wins and incidents for tie-breaking
+func summaryEntry(summary database.Summary, value float64) rank.Entry {
+	return rank.Entry{
+		Name:      summary.Driver.Name,
+		Value:     value,
+		Wins:      summary.Wins,
+		Incidents: summary.AverageIncidentsPerLap,
+	}
+}
+
+// rankSummaries sorts summaries by the given value, and returns their positions
+func rankSummaries(summaries []database.Summary, value func(s database.Summary) float64, ascending bool, breakers []rank.TieBreaker) []int {
+	entries := make([]rank.Entry, 0, len(summaries))
+	for _, summary := range summaries {
+		entries = append(entries, summaryEntry(summary, value(summary)))
+	}
+	order, positions := rank.Order(entries, ascending, breakers)
+	sorted := make([]database.Summary, 0, len(summaries))
+	for _, o := range order {
+		sorted = append(sorted, summaries[o])
+	}
+	copy(summaries, sorted)
+	return positions
+}
+
+// rankStandings sorts standings by points and sets their positions, with ties broken by the given tie-breakers.
+// The statistics for tie-breaking are looked up by driverID, drivers without any are ranked as if they had none
+func rankStandings(standings []standing, stats map[int]rank.Entry, breakers []rank.TieBreaker) []standing {
+	entries := make([]rank.Entry, 0, len(standings))
+	for _, s := range standings {
+		entry := stats[s.Driver.DriverID]
+		entry.Name = s.Driver.Name
+		entry.Value = float64(s.Points)
+		entries = append(entries, entry)
+	}
+	order, positions := rank.Order(entries, false, breakers)
+	sorted := make([]standing, 0, len(standings))
+	for p, o := range order {
+		s := standings[o]
+		s.Position = positions[p]
+		sorted = append(sorted, s)
+	}
+	copy(standings, sorted)
+	return standings
+}
+
+// standingStats collects the tie-breaking statistics of all drivers in the given weeks, by driverID.
+// The weekly results are the championship points, or the time trial points if tt is set,
+// wins and incidents come from the race summaries and are only collected if needed
+func (h *Handler) standingStats(seasonID int, weeks []weeklyPoints, tt bool, breakers []rank.TieBreaker) (map[int]rank.Entry, error) {
+	stats := make(map[int]rank.Entry)
+	if len(breakers) == 0 {
+		return stats, nil
+	}
+	results := make(map[int][]float64)
+	for w := len(weeks) - 1; w >= 0; w-- { // most recent week first
+		weekly := make(map[int]float64)
+		if tt {
+			for driver, points := range weeks[w].TTPoints {
+				for _, p := range points {
+					weekly[driver.DriverID] += float64(p)
+				}
+			}
+		} else {
+			for driver, points := range weeks[w].ChampPoints {
+				weekly[driver.DriverID] += points
+			}
+		}
+		for driverID, result := range weekly {
+			for len(results[driverID]) < len(weeks)-1-w { // no results in the more recent weeks
+				results[driverID] = append(results[driverID], 0)
+			}
+			results[driverID] = append(results[driverID], result)
+		}
+	}
+	for driverID, r := range results {
+		stats[driverID] = rank.Entry{Results: r}
+	}
+
+	if !needsSummaries(breakers) {
+		return stats, nil
+	}
+	summaries := make([]database.Summary, 0)
+	for _, week := range weeks {
+		weeklySummaries, err := h.getRaceWeekSummaries(seasonID, week.Week)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, weeklySummaries...)
+	}
+	for _, summary := range mergeSummaries(summaries) {
+		entry := stats[summary.Driver.DriverID]
+		entry.Wins = summary.Wins
+		entry.Incidents = summary.AverageIncidentsPerLap
+		stats[summary.Driver.DriverID] = entry
+	}
+	return stats, nil
+}
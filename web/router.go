@@ -78,16 +78,58 @@ func router(h *Handler) *mux.Router {
 	r.HandleFunc("/season/{seasonID}/oval_standing.png", h.ovalRanking)
 	r.HandleFunc("/season/{seasonID}/oval_rankings.png", h.ovalRanking)
 	r.HandleFunc("/season/{seasonID}/oval_ranking.png", h.ovalRanking)
+	r.HandleFunc("/season/{seasonID}/standings.json", h.rankingJson)
+	r.HandleFunc("/season/{seasonID}/oval_standings.json", h.ovalRankingJson)
+
+	// dynamic championship projection
+	r.HandleFunc("/season/{seasonID}/projection.png", h.seasonProjection)
+	r.HandleFunc("/season/{seasonID}/projection.json", h.seasonProjectionJson)
+
+	// dynamic championship positions / bump chart
+	r.HandleFunc("/season/{seasonID}/positions.png", h.positions)
+	r.HandleFunc("/season/{seasonID}/bumpchart.png", h.positions)
+
+	// dynamic head-to-head driver comparison
+	r.HandleFunc("/season/{seasonID}/h2h.png", h.seasonH2H)
+
+	// dynamic team championship standings
+	r.HandleFunc("/season/{seasonID}/team_ranking.png", h.teamRanking)
+	r.HandleFunc("/season/{seasonID}/team_rankings.png", h.teamRanking)
+	r.HandleFunc("/season/{seasonID}/team_standings.png", h.teamRanking)
+
+	// dynamic club standings
+	r.HandleFunc("/season/{seasonID}/clubs.png", h.clubRanking)
+	r.HandleFunc("/season/{seasonID}/club_ranking.png", h.clubRanking)
+	r.HandleFunc("/season/{seasonID}/clubs.json", h.clubRankingJson)
+	r.HandleFunc("/season/{seasonID}/week/{week}/clubs.png", h.clubRanking)
+	r.HandleFunc("/season/{seasonID}/week/{week}/club_ranking.png", h.clubRanking)
+	r.HandleFunc("/season/{seasonID}/week/{week}/clubs.json", h.clubRankingJson)
+
+	// driver lookup, to find the IDs of drivers to mark
+	r.HandleFunc("/season/{seasonID}/drivers.json", h.driverSearch)
+
+	// dynamic season schedule and calendar
+	r.HandleFunc("/season/{seasonID}/schedule.png", h.seasonSchedule)
+	r.HandleFunc("/season/{seasonID}/calendar.ics", h.seasonCalendar)
 
 	// dynamic heatmap
 	r.HandleFunc("/season/{seasonID}/week/{week}/heatmap.png", h.weeklyHeatmap)
 	r.HandleFunc("/season/{seasonID}/heatmap.png", h.seasonalHeatmap)
+	r.HandleFunc("/series/{seriesID}/heatmap.png", h.seriesHeatmap)
 
 	// dynamic scores
-	r.HandleFunc("/season/{seasonID}/week/{week}/top/scores.png", h.weeklyTopScores)
-	r.HandleFunc("/season/{seasonID}/week/{week}/top/racers.png", h.weeklyTopRacers)
-	r.HandleFunc("/season/{seasonID}/week/{week}/top/laps.png", h.weeklyTopLaps)
-	r.HandleFunc("/season/{seasonID}/week/{week}/top/safety.png", h.weeklyTopSafety)
+	r.HandleFunc("/season/{seasonID}/week/{week}/top/scores.png", h.topScores)
+	r.HandleFunc("/season/{seasonID}/week/{week}/top/racers.png", h.topRacers)
+	r.HandleFunc("/season/{seasonID}/week/{week}/top/laps.png", h.topLaps)
+	r.HandleFunc("/season/{seasonID}/week/{week}/top/safety.png", h.topSafety)
+	r.HandleFunc("/season/{seasonID}/top/scores.png", h.topScores)
+	r.HandleFunc("/season/{seasonID}/top/racers.png", h.topRacers)
+	r.HandleFunc("/season/{seasonID}/top/laps.png", h.topLaps)
+	r.HandleFunc("/season/{seasonID}/top/safety.png", h.topSafety)
+	r.HandleFunc("/season/{seasonID}/week/{week}/top/{preset:[a-z0-9_]+}.png", h.topPreset)
+	r.HandleFunc("/season/{seasonID}/top/{preset:[a-z0-9_]+}.png", h.topPreset)
+	r.HandleFunc("/season/{seasonID}/week/{week}/top.png", h.topCustom)
+	r.HandleFunc("/season/{seasonID}/top.png", h.topCustom)
 
 	// dynamic driver summaries
 	r.HandleFunc("/season/{seasonID}/summary.png", h.seasonSummary)
@@ -96,6 +138,14 @@ func router(h *Handler) *mux.Router {
 	// dynamic laptime chart
 	r.HandleFunc("/season/{seasonID}/week/{week}/laptimes.png", h.weeklyLaptimes)
 
+	// dynamic laptime distribution
+	r.HandleFunc("/season/{seasonID}/week/{week}/laptime_distribution.png", h.weeklyLaptimeDistribution)
+	r.HandleFunc("/season/{seasonID}/week/{week}/distribution.png", h.weeklyLaptimeDistribution)
+
+	// dynamic pace scatter plot
+	r.HandleFunc("/season/{seasonID}/week/{week}/pace.png", h.weeklyPace)
+	r.HandleFunc("/season/{seasonID}/week/{week}/scatter.png", h.weeklyPace)
+
 	// catch-all
 	r.PathPrefix("/").HandlerFunc(h.index)
 
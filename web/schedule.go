@@ -0,0 +1,210 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image/schedule"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/util"
+	"github.com/gorilla/mux"
+)
+
+var scheduleMutex = &sync.Mutex{}
+
+func (h *Handler) seasonSchedule(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("schedule: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value := req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("schedule: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// was there a timezone given?
+	location, err := getLocation(req)
+	if err != nil {
+		log.Errorf("schedule: could not load timezone [%s]: %v", req.URL.Query().Get("tz"), err)
+		h.failure(rw, req, err)
+		return
+	}
+	variants := make([]string, 0)
+	if location.String() != time.UTC.String() {
+		variants = append(variants, "tz_"+location.String())
+	}
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && schedule.IsAvailable(colorScheme, seasonID, variants...) {
+		http.ServeFile(rw, req, schedule.Filename(seasonID, variants...))
+		return
+	}
+	// lock global mutex
+	scheduleMutex.Lock()
+	defer scheduleMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && schedule.IsAvailable(colorScheme, seasonID, variants...) {
+		http.ServeFile(rw, req, schedule.Filename(seasonID, variants...))
+		return
+	}
+
+	// create/update schedule image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("schedule: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	data := make([]schedule.DataRow, 0)
+	for week := 0; week < 13; week++ {
+		_, track, err := h.getRaceWeek(seasonID, week)
+		if err != nil {
+			if week < 12 { // leap weeks are optional
+				log.Debugf("schedule: could not get raceweek for season[%d], week[%d]: %v", seasonID, week, err)
+				data = append(data, scheduleRow(season, week, database.Track{Name: "to be announced..."}, location))
+			}
+			continue
+		}
+		data = append(data, scheduleRow(season, week, track, location))
+	}
+
+	zone, _ := time.Now().In(location).Zone()
+	s := schedule.New(colorScheme, season, zone, data, variants...)
+	if err := s.Draw(); err != nil {
+		log.Errorf("schedule: could not create season schedule: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, schedule.Filename(seasonID, variants...))
+}
+
+func scheduleRow(season database.Season, week int, track database.Track, location *time.Location) schedule.DataRow {
+	weekStart, weekEnd := raceWeekBounds(season, week)
+	raceTimes := "-"
+	sessions, err := util.SessionTimes(season.Timeslots, weekStart, weekEnd)
+	if err != nil {
+		log.Errorf("schedule: %v", err)
+	} else {
+		raceTimes = describeSessions(sessions, location)
+	}
+	return schedule.DataRow{
+		Week:      week,
+		Start:     weekStart,
+		End:       weekEnd,
+		Track:     scheduleTrack(track),
+		Category:  scheduleCategory(track),
+		RaceTimes: raceTimes,
+		Current:   time.Now().After(weekStart) && time.Now().Before(weekEnd),
+	}
+}
+
+// raceWeekBounds returns the start and end of a raceweek of a season
+func raceWeekBounds(season database.Season, week int) (time.Time, time.Time) {
+	weekStart := database.WeekStart(season.StartDate.UTC().AddDate(0, 0, week*7))
+	return weekStart, weekStart.AddDate(0, 0, 7)
+}
+
+// scheduleTrack returns the name of a track, including its config if there is one
+func scheduleTrack(track database.Track) string {
+	if len(track.Config) > 0 {
+		return fmt.Sprintf("%s - %s", track.Name, track.Config)
+	}
+	return track.Name
+}
+
+// scheduleCategory returns the readable category of a track, like "Road" or "Dirt Oval"
+func scheduleCategory(track database.Track) string {
+	if track.TrackID == 0 {
+		return ""
+	}
+	return categoryName(trackCategory(track))
+}
+
+// describeSessions returns a short description of all session times of a raceweek, like "every 2h at :15"
+func describeSessions(sessions []time.Time, location *time.Location) string {
+	if len(sessions) == 0 {
+		return "-"
+	}
+
+	// collect local times of day per weekday
+	days := make(map[time.Weekday][]string)
+	weekdays := make([]time.Weekday, 0)
+	for _, session := range sessions {
+		local := session.In(location)
+		if _, ok := days[local.Weekday()]; !ok {
+			weekdays = append(weekdays, local.Weekday())
+		}
+		days[local.Weekday()] = append(days[local.Weekday()], local.Format("15:04"))
+	}
+	for _, times := range days {
+		sort.Strings(times) // raceweeks don't start at local midnight
+	}
+
+	// same times every day?
+	daily := len(weekdays) == 7
+	for _, weekday := range weekdays {
+		if strings.Join(days[weekday], ",") != strings.Join(days[weekdays[0]], ",") {
+			daily = false
+		}
+	}
+	if daily {
+		times := days[weekdays[0]]
+		if len(times) == 1 {
+			return fmt.Sprintf("daily at %s", times[0])
+		}
+		// evenly spaced over the whole day?
+		first, _ := time.Parse("15:04", times[0])
+		second, _ := time.Parse("15:04", times[1])
+		interval := second.Sub(first)
+		even := interval > 0 && interval%time.Hour == 0 && time.Duration(len(times))*interval == 24*time.Hour
+		for t := 1; even && t < len(times); t++ {
+			previous, _ := time.Parse("15:04", times[t-1])
+			current, _ := time.Parse("15:04", times[t])
+			even = current.Sub(previous) == interval
+		}
+		if even {
+			return fmt.Sprintf("every %dh at :%s", int(interval.Hours()), times[0][3:])
+		}
+		if len(times) > 4 {
+			times = append(times[:4], "...")
+		}
+		return fmt.Sprintf("daily at %s", strings.Join(times, ", "))
+	}
+
+	// list individual days
+	list := make([]string, 0)
+	for _, weekday := range weekdays {
+		list = append(list, fmt.Sprintf("%s %s", weekday.String()[:3], strings.Join(days[weekday], " & ")))
+	}
+	if len(list) > 4 {
+		list = append(list[:4], "...")
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,244 @@
+package web
+
+import (
+	"math"
+	"sort"
+
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/scoring"
+)
+
+// weeklyPoints holds the championship and time trial points of all drivers for a single raceweek
+type weeklyPoints struct {
+	Week        int
+	ChampPoints map[database.Driver]float64
+	TTPoints    map[database.Driver][]int
+}
+
+type standing struct {
+	Driver   database.Driver
+	Points   int
+	Position int // shared by drivers with equal points
+}
+
+// getWeeklyPoints collects the weekly championship and time trial points of a season,
+// only for the given car class if not 0 and only of weeks with a track of the given category if not empty.
+// The car classes of each week are only collected if not already given
+func (h *Handler) getWeeklyPoints(seasonID, carClass int, weeklyClasses []carClasses, category string, rules scoring.Rules) ([]weeklyPoints, error) {
+	if carClass > 0 && weeklyClasses == nil {
+		var err error
+		weeklyClasses, err = h.getWeeklyCarClasses(seasonID)
+		if err != nil {
+			log.Errorf("could not get car classes: %v", err)
+			return nil, err
+		}
+	}
+
+	points := make([]weeklyPoints, 0)
+	for week := 0; week < 13; week++ { // allow for leap seasons with 13 official weeks, like 2020S3
+		isCategory, err := h.isCategoryWeek(seasonID, week, category)
+		if err != nil {
+			return nil, err
+		}
+		if !isCategory {
+			continue
+		}
+		weeklyCcPoints, err := h.getChampPoints(seasonID, week)
+		if err != nil {
+			log.Errorf("could not get championship points for week [%d]: %v", week+1, err)
+			return nil, err
+		}
+		var weeklyTtResults []database.TimeTrialResult
+		if carClass > 0 {
+			weeklyTtResults, err = h.getTTStandingsByCarClass(seasonID, week, carClass)
+		} else {
+			weeklyTtResults, err = h.getTTStandings(seasonID, week)
+		}
+		if err != nil {
+			log.Errorf("could not get TT results for week [%d]: %v", week+1, err)
+			return nil, err
+		}
+
+		// do we have data for this week?
+		if len(weeklyCcPoints) == 0 && len(weeklyTtResults) == 0 {
+			continue
+		}
+
+		// only keep race results of the given car class
+		if carClass > 0 && len(weeklyCcPoints) > 0 {
+			filtered := make([]database.Points, 0)
+			for _, p := range weeklyCcPoints {
+				if weeklyClasses[week].hasResult(carClass, p.SubsessionID, p.Driver.DriverID) {
+					filtered = append(filtered, p)
+				}
+			}
+			weeklyCcPoints = filtered
+		}
+
+		wp := weeklyPoints{
+			Week:        week,
+			ChampPoints: make(map[database.Driver]float64),
+			TTPoints:    make(map[database.Driver][]int),
+		}
+
+		// collect champpoints for all drivers
+		drivers := make(map[database.Driver][]int)
+		for _, p := range weeklyCcPoints {
+			drivers[p.Driver] = append(drivers[p.Driver], p.ChampPoints)
+		}
+		// figure out points for each driver this week
+		for driver, values := range drivers {
+			wp.ChampPoints[driver] = rules.WeeklyPoints(values)
+		}
+
+		// collect TT points for all drivers
+		for _, tt := range weeklyTtResults {
+			wp.TTPoints[tt.Driver] = append(wp.TTPoints[tt.Driver], tt.Points)
+		}
+		points = append(points, wp)
+	}
+	return points, nil
+}
+
+// champStandings calculates the championship standings after all given weeks, counting only the best weeks of each driver
+func champStandings(weeks []weeklyPoints, rules scoring.Rules) []standing {
+	ccPoints := make(map[database.Driver][]float64)
+	for _, week := range weeks {
+		for driver, value := range week.ChampPoints {
+			ccPoints[driver] = append(ccPoints[driver], value)
+		}
+	}
+	bestN := rules.CountedWeeks(len(weeks))
+
+	standings := make([]standing, 0)
+	for driver, values := range ccPoints {
+		sort.Slice(values, func(i, j int) bool {
+			return values[i] > values[j]
+		})
+		total := rules.Bonus(len(values))
+		for n := 0; n < bestN && n < len(values); n++ {
+			total += values[n]
+		}
+		standings = append(standings, standing{
+			Driver: driver,
+			Points: int(math.Floor(total)),
+		})
+	}
+	sortStandings(standings)
+	return standings
+}
+
+// ttStandings calculates the time trial standings after all given weeks, counting only the best weeks of each driver
+func ttStandings(weeks []weeklyPoints, rules scoring.Rules) []standing {
+	ttPoints := make(map[database.Driver][]int)
+	for _, week := range weeks {
+		for driver, values := range week.TTPoints {
+			ttPoints[driver] = append(ttPoints[driver], values...)
+		}
+	}
+	bestN := rules.CountedWeeks(len(weeks))
+
+	standings := make([]standing, 0)
+	for driver, values := range ttPoints {
+		sort.Slice(values, func(i, j int) bool {
+			return values[i] > values[j]
+		})
+		var total int
+		for n := 0; n < bestN && n < len(values); n++ {
+			total += values[n]
+		}
+		standings = append(standings, standing{
+			Driver: driver,
+			Points: total,
+		})
+	}
+	sortStandings(standings)
+	return standings
+}
+
+// sortStandings sorts by points, and by driver name for equal points which share the same position
+func sortStandings(standings []standing) {
+	rankStandings(standings, nil, nil)
+}
+
+// teamStanding holds the championship points of a team and the points each of its drivers contributed to it
+type teamStanding struct {
+	Team    string
+	Points  int
+	Drivers []standing
+}
+
+// teamWeek holds the points a team scored in a single raceweek, and which drivers scored them
+type teamWeek struct {
+	Points  float64
+	Drivers map[database.Driver]float64
+}
+
+// teamStandings calculates the team championship standings after all given weeks,
+// counting only the bestDrivers results of each team per week and the bestN weeks of each team
+func teamStandings(weeks []weeklyPoints, bestDrivers, bestN int) []teamStanding {
+	teamWeeks := make(map[string][]teamWeek)
+	for _, week := range weeks {
+		// collect champpoints of all team members
+		members := make(map[string][]database.Driver)
+		for driver := range week.ChampPoints {
+			if len(driver.Team) == 0 {
+				continue
+			}
+			members[driver.Team] = append(members[driver.Team], driver)
+		}
+
+		// only the best drivers of a team score for it
+		for team, drivers := range members {
+			sort.Slice(drivers, func(i, j int) bool {
+				if week.ChampPoints[drivers[i]] == week.ChampPoints[drivers[j]] {
+					return drivers[i].Name < drivers[j].Name
+				}
+				return week.ChampPoints[drivers[i]] > week.ChampPoints[drivers[j]]
+			})
+			tw := teamWeek{Drivers: make(map[database.Driver]float64)}
+			for n := 0; n < bestDrivers && n < len(drivers); n++ {
+				value := week.ChampPoints[drivers[n]]
+				tw.Points += value
+				tw.Drivers[drivers[n]] = value
+			}
+			teamWeeks[team] = append(teamWeeks[team], tw)
+		}
+	}
+
+	standings := make([]teamStanding, 0)
+	for team, values := range teamWeeks {
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].Points > values[j].Points
+		})
+		var total float64
+		contributions := make(map[int]float64) // by driverID, since the division of a driver can change between weeks
+		drivers := make(map[int]database.Driver)
+		for n := 0; n < bestN && n < len(values); n++ {
+			total += values[n].Points
+			for driver, value := range values[n].Drivers {
+				contributions[driver.DriverID] += value
+				drivers[driver.DriverID] = driver
+			}
+		}
+
+		ts := teamStanding{
+			Team:    team,
+			Points:  int(math.Floor(total)),
+			Drivers: make([]standing, 0),
+		}
+		for driverID, value := range contributions {
+			ts.Drivers = append(ts.Drivers, standing{Driver: drivers[driverID], Points: int(math.Floor(value))})
+		}
+		sortStandings(ts.Drivers)
+		standings = append(standings, ts)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points == standings[j].Points {
+			return standings[i].Team < standings[j].Team
+		}
+		return standings[i].Points > standings[j].Points
+	})
+	return standings
+}
@@ -1,8 +1,8 @@
 package web
 
 import (
+	"fmt"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -72,18 +72,63 @@ func (h *Handler) weeklySummary(rw http.ResponseWriter, req *http.Request) {
 	// is there a team given?
 	team := req.URL.Query().Get("team")
 
+	// was there a division given?
+	division, err := getDivision(req, 0)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	if division == divisionAll {
+		h.failure(rw, req, fmt.Errorf("division [all] is not supported for summaries, use a division 1-10"))
+		return
+	}
+	variants := divisionVariants(division)
+
+	// was there a track category given?
+	category, categoryVariants, err := getCategory(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, categoryVariants...)
+
+	// was there a minimum participation given?
+	required, err := getEligibility(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	required = summaryEligibility.merge(required)
+	variants = append(variants, required.variants()...)
+
+	// were there any tie-breakers given?
+	breakers, breakerVariants, err := getTieBreakers(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, breakerVariants...)
+
+	// were there any track or car details requested?
+	enrichment, err := getEnrichment(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, enrichment.Variants()...)
+
 	// do we need to update the image file?
 	// check if file already exists and is up-to-date, serve it immediately if yes
-	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, week, team) {
-		http.ServeFile(rw, req, summary.Filename(seasonID, week, team))
+	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, summary.Filename(seasonID, week, team, variants...))
 		return
 	}
 	// lock global mutex
 	summaryMutex.Lock()
 	defer summaryMutex.Unlock()
 	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
-	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, week, team) {
-		http.ServeFile(rw, req, summary.Filename(seasonID, week, team))
+	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, week, team, variants...) {
+		http.ServeFile(rw, req, summary.Filename(seasonID, week, team, variants...))
 		return
 	}
 
@@ -101,6 +146,9 @@ func (h *Handler) weeklySummary(rw http.ResponseWriter, req *http.Request) {
 		raceweek.LastUpdate = time.Now()
 		track.Name = "starting soon..."
 	}
+	if enrichment.CarLogos {
+		enrichment.Cars = h.getRaceWeekCars(raceweek.RaceWeekID)
+	}
 	var summaries []database.Summary
 	if len(team) > 0 {
 		summaries, err = h.getRaceWeekSummariesByTeam(seasonID, week-1, team)
@@ -118,19 +166,37 @@ func (h *Handler) weeklySummary(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	// only the results of weeks with a track of the given category
+	if !hasCategory(category, track) {
+		summaries = []database.Summary{}
+	}
+	races := map[int]raceCount{}
+	if required.needsRaces() {
+		races, err = h.raceCounts(seasonID, []int{week - 1}, category)
+		if err != nil {
+			log.Errorf("summary: could not count races for season[%d]: %v", seasonID, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	summaries = eligibleSummaries(required, divisionSummaries(division, summaries), races)
+
 	data := make([]summary.DataSet, 0)
 	// sort by champ points
-	sort.Slice(summaries, func(i, j int) bool {
-		return summaries[i].HighestChampPoints > summaries[j].HighestChampPoints
-	})
+	positions := rankSummaries(summaries, func(s database.Summary) float64 {
+		return float64(s.HighestChampPoints)
+	}, false, breakers)
 	for i := 0; i < topN && i < len(summaries); i++ {
 		data = append(data, summary.DataSet{
-			Summary: summaries[i],
-			Marked:  isDriverMarked(drivers, summaries[i].Driver.DriverID),
+			Position: positions[i],
+			Summary:  summaries[i],
+			Marked:   isDriverMarked(drivers, summaries[i].Driver),
 		})
 	}
 
-	hm := summary.New(colorScheme, team, season, raceweek, track, data)
+	hm := summary.New(colorScheme, team, season, raceweek, track, data, variants...)
+	hm.Eligibility = required.note()
+	hm.Enrichment = enrichment
 	if err := hm.Draw(); err != nil {
 		log.Errorf("summary: could not create weekly summary [%s]: %v", image, err)
 		h.failure(rw, req, err)
@@ -138,7 +204,7 @@ func (h *Handler) weeklySummary(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	// serve new/updated image
-	http.ServeFile(rw, req, summary.Filename(seasonID, week, team))
+	http.ServeFile(rw, req, summary.Filename(seasonID, week, team, variants...))
 }
 
 func (h *Handler) seasonSummary(rw http.ResponseWriter, req *http.Request) {
@@ -191,18 +257,55 @@ func (h *Handler) seasonSummary(rw http.ResponseWriter, req *http.Request) {
 		team = "TNT Racing"
 	}
 
+	// was there a division given?
+	division, err := getDivision(req, 0)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	if division == divisionAll {
+		h.failure(rw, req, fmt.Errorf("division [all] is not supported for summaries, use a division 1-10"))
+		return
+	}
+	variants := divisionVariants(division)
+
+	// was there a track category given?
+	category, categoryVariants, err := getCategory(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, categoryVariants...)
+
+	// was there a minimum participation given?
+	required, err := getEligibility(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	required = summaryEligibility.merge(required)
+	variants = append(variants, required.variants()...)
+
+	// were there any tie-breakers given?
+	breakers, breakerVariants, err := getTieBreakers(req)
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+	variants = append(variants, breakerVariants...)
+
 	// do we need to update the image file?
 	// check if file already exists and is up-to-date, serve it immediately if yes
-	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, -1, team) {
-		http.ServeFile(rw, req, summary.Filename(seasonID, -1, team))
+	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, -1, team, variants...) {
+		http.ServeFile(rw, req, summary.Filename(seasonID, -1, team, variants...))
 		return
 	}
 	// lock global mutex
 	summaryMutex.Lock()
 	defer summaryMutex.Unlock()
 	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
-	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, -1, team) {
-		http.ServeFile(rw, req, summary.Filename(seasonID, -1, team))
+	if !forceOverwrite && summary.IsAvailable(colorScheme, seasonID, -1, team, variants...) {
+		http.ServeFile(rw, req, summary.Filename(seasonID, -1, team, variants...))
 		return
 	}
 
@@ -214,26 +317,43 @@ func (h *Handler) seasonSummary(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 	var summaries []database.Summary
-	summaries, err = h.getSeasonSummariesByTeam(seasonID, team)
+	if len(category) > 0 {
+		summaries, err = h.getSeasonSummariesByTeamAndCategory(seasonID, team, category)
+	} else {
+		summaries, err = h.getSeasonSummariesByTeam(seasonID, team)
+	}
 	if err != nil {
 		log.Errorf("summary: could not get season summaries for season[%d], team[%s]: %v", seasonID, team, err)
 		h.failure(rw, req, err)
 		return
 	}
 
+	races := map[int]raceCount{}
+	if required.needsRaces() {
+		races, err = h.raceCounts(seasonID, topWeeks(-1), category)
+		if err != nil {
+			log.Errorf("summary: could not count races for season[%d]: %v", seasonID, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	summaries = eligibleSummaries(required, divisionSummaries(division, summaries), races)
+
 	data := make([]summary.DataSet, 0)
 	// sort by champ points
-	sort.Slice(summaries, func(i, j int) bool {
-		return summaries[i].AverageChampPoints > summaries[j].AverageChampPoints
-	})
+	positions := rankSummaries(summaries, func(s database.Summary) float64 {
+		return float64(s.AverageChampPoints)
+	}, false, breakers)
 	for i := 0; i < topN && i < len(summaries); i++ {
 		data = append(data, summary.DataSet{
-			Summary: summaries[i],
-			Marked:  isDriverMarked(drivers, summaries[i].Driver.DriverID),
+			Position: positions[i],
+			Summary:  summaries[i],
+			Marked:   isDriverMarked(drivers, summaries[i].Driver),
 		})
 	}
 
-	hm := summary.New(colorScheme, team, season, database.RaceWeek{RaceWeek: -1, LastUpdate: time.Now()}, database.Track{}, data)
+	hm := summary.New(colorScheme, team, season, database.RaceWeek{RaceWeek: -1, LastUpdate: time.Now()}, database.Track{}, data, variants...)
+	hm.Eligibility = required.note()
 	if err := hm.Draw(); err != nil {
 		log.Errorf("summary: could not create season summary [%s]: %v", image, err)
 		h.failure(rw, req, err)
@@ -241,5 +361,54 @@ func (h *Handler) seasonSummary(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	// serve new/updated image
-	http.ServeFile(rw, req, summary.Filename(seasonID, -1, team))
+	http.ServeFile(rw, req, summary.Filename(seasonID, -1, team, variants...))
+}
+
+// mergeSummaries combines the weekly summaries of each driver into a single one
+func mergeSummaries(summaries []database.Summary) []database.Summary {
+	drivers := make([]int, 0)
+	merged := make(map[int]database.Summary)
+	for _, summary := range summaries {
+		m, ok := merged[summary.Driver.DriverID]
+		if !ok {
+			drivers = append(drivers, summary.Driver.DriverID)
+			merged[summary.Driver.DriverID] = summary
+			continue
+		}
+		// averages are weighted by laps and races
+		if m.LapsCompleted+summary.LapsCompleted > 0 {
+			m.AverageIncidentsPerLap = (m.AverageIncidentsPerLap*float64(m.LapsCompleted) + summary.AverageIncidentsPerLap*float64(summary.LapsCompleted)) /
+				float64(m.LapsCompleted+summary.LapsCompleted)
+		}
+		if m.NumberOfRaces+summary.NumberOfRaces > 0 {
+			m.AverageChampPoints = (m.AverageChampPoints*m.NumberOfRaces + summary.AverageChampPoints*summary.NumberOfRaces) /
+				(m.NumberOfRaces + summary.NumberOfRaces)
+		}
+		if summary.HighestIRatingGain > m.HighestIRatingGain {
+			m.HighestIRatingGain = summary.HighestIRatingGain
+		}
+		if summary.HighestChampPoints > m.HighestChampPoints {
+			m.HighestChampPoints = summary.HighestChampPoints
+		}
+		m.Driver = summary.Driver // latest known division and team
+		m.Division = summary.Division
+		m.TotalIRatingGain += summary.TotalIRatingGain
+		m.TotalSafetyRatingGain += summary.TotalSafetyRatingGain
+		m.LapsCompleted += summary.LapsCompleted
+		m.LapsLead += summary.LapsLead
+		m.Poles += summary.Poles
+		m.Wins += summary.Wins
+		m.Podiums += summary.Podiums
+		m.Top5 += summary.Top5
+		m.TotalPositionsGained += summary.TotalPositionsGained
+		m.TotalClubPoints += summary.TotalClubPoints
+		m.NumberOfRaces += summary.NumberOfRaces
+		merged[summary.Driver.DriverID] = m
+	}
+
+	result := make([]database.Summary, 0)
+	for _, driverID := range drivers {
+		result = append(result, merged[driverID])
+	}
+	return result
 }
@@ -0,0 +1,171 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/JamesClonk/iRvisualizer/image/teams"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/scoring"
+	"github.com/gorilla/mux"
+)
+
+var teamsMutex = &sync.Mutex{}
+
+func (h *Handler) teamRanking(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	seasonID, err := strconv.Atoi(vars["seasonID"])
+	if err != nil {
+		log.Errorf("teams: could not convert seasonID [%s] to int: %v", vars["seasonID"], err)
+		h.failure(rw, req, err)
+		return
+	}
+	if seasonID < 2000 || seasonID > 9999 {
+		seasonID = 2377
+	}
+
+	// was there a colorScheme given?
+	colorScheme := req.URL.Query().Get("colorScheme")
+
+	// was there a topN given?
+	topN := 10
+	value := req.URL.Query().Get("topN")
+	if len(value) > 0 {
+		topN, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("teams: could not convert topN [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if topN < 1 || topN > 25 {
+		topN = 10
+	}
+
+	// how many drivers per team are scoring each week?
+	bestDrivers := 2
+	value = req.URL.Query().Get("bestDrivers")
+	if len(value) > 0 {
+		bestDrivers, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("teams: could not convert bestDrivers [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if bestDrivers < 1 || bestDrivers > 10 {
+		bestDrivers = 2
+	}
+
+	// was there a number of dropweeks given? defaults to a third of all weeks, like the driver championship
+	dropWeeks := -1
+	value = req.URL.Query().Get("dropWeeks")
+	if len(value) > 0 {
+		dropWeeks, err = strconv.Atoi(value)
+		if err != nil {
+			log.Errorf("teams: could not convert dropWeeks [%s] to int: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+	if dropWeeks > 12 {
+		dropWeeks = -1
+	}
+
+	// was there a forceOverwrite given?
+	forceOverwrite := false
+	value = req.URL.Query().Get("forceOverwrite")
+	if len(value) > 0 {
+		forceOverwrite, err = strconv.ParseBool(value)
+		if err != nil {
+			log.Errorf("teams: could not convert forceOverwrite [%s] to bool: %v", value, err)
+			h.failure(rw, req, err)
+			return
+		}
+	}
+
+	// is there a team given?
+	team := req.URL.Query().Get("team")
+
+	// non-default counting rules and sizes need their own image file
+	variants := make([]string, 0)
+	if topN != 10 {
+		variants = append(variants, fmt.Sprintf("top%d", topN))
+	}
+	if bestDrivers != 2 {
+		variants = append(variants, fmt.Sprintf("best%d", bestDrivers))
+	}
+	if dropWeeks >= 0 {
+		variants = append(variants, fmt.Sprintf("drop%d", dropWeeks))
+	}
+
+	// do we need to update the image file?
+	// check if file already exists and is up-to-date, serve it immediately if yes
+	if !forceOverwrite && teams.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, teams.Filename(seasonID, team, variants...))
+		return
+	}
+	// lock global mutex
+	teamsMutex.Lock()
+	defer teamsMutex.Unlock()
+	// doublecheck, to make sure it wasn't updated by now by another goroutine that held the lock before
+	if !forceOverwrite && teams.IsAvailable(colorScheme, seasonID, team, variants...) {
+		http.ServeFile(rw, req, teams.Filename(seasonID, team, variants...))
+		return
+	}
+
+	// create/update team ranking image
+	season, err := h.getSeason(seasonID)
+	if err != nil {
+		log.Errorf("teams: could not get season: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+	// collect champ points for all weeks
+	points, err := h.getWeeklyPoints(seasonID, 0, nil, "", scoring.IRacing())
+	if err != nil {
+		h.failure(rw, req, err)
+		return
+	}
+
+	// how many weeks are counted?
+	bestN := scoring.IRacing().CountedWeeks(len(points))
+	if dropWeeks >= 0 {
+		bestN = len(points) - dropWeeks
+	}
+	if bestN < 1 {
+		bestN = 1
+	}
+
+	data := make([]teams.DataRow, 0)
+	for t, ts := range teamStandings(points, bestDrivers, bestN) {
+		if t >= topN {
+			break
+		}
+		row := teams.DataRow{
+			Team:    ts.Team,
+			Value:   fmt.Sprintf("%d", ts.Points),
+			Drivers: make([]teams.DataDriver, 0),
+			Marked:  ts.Team == team && len(team) > 0,
+		}
+		for _, driver := range ts.Drivers {
+			row.Drivers = append(row.Drivers, teams.DataDriver{
+				Driver: driver.Driver.Name,
+				Value:  fmt.Sprintf("%d", driver.Points),
+			})
+		}
+		data = append(data, row)
+	}
+
+	t := teams.New(colorScheme, team, season, data, variants...)
+	if err := t.Draw(bestDrivers, bestN, len(points)); err != nil {
+		log.Errorf("teams: could not create team ranking: %v", err)
+		h.failure(rw, req, err)
+		return
+	}
+
+	// serve new/updated image
+	http.ServeFile(rw, req, teams.Filename(seasonID, team, variants...))
+}
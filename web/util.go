@@ -1,12 +1,104 @@
 package web
 
-import "strconv"
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
-func isDriverMarked(drivers []string, driverID int) bool {
-	for _, driver := range drivers {
-		if strconv.Itoa(driverID) == driver {
+	"github.com/JamesClonk/iRcollector/database"
+	"github.com/JamesClonk/iRvisualizer/image"
+	"github.com/JamesClonk/iRvisualizer/log"
+	"github.com/JamesClonk/iRvisualizer/scoring"
+	"github.com/JamesClonk/iRvisualizer/util"
+)
+
+var slugRx = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns any user given value into a lowercase string usable as an image file variant
+func slug(value string) string {
+	return strings.Trim(slugRx.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+// isDriverMarked checks if a driver is one of the marked drivers, given either by their ID or by their full name ignoring case
+func isDriverMarked(drivers []string, driver database.Driver) bool {
+	for _, marked := range drivers {
+		marked = strings.TrimSpace(marked)
+		if len(marked) == 0 {
+			continue
+		}
+		if strconv.Itoa(driver.DriverID) == marked || strings.EqualFold(driver.Name, marked) {
 			return true
 		}
 	}
 	return false
 }
+
+// getReferenceLap reads the optional reference lap and its name from the "laptime" and "reference" query parameters
+func getReferenceLap(req *http.Request) (database.Laptime, string) {
+	var refLap int
+	lap := req.URL.Query().Get("laptime")
+	if strings.Contains(lap, "s") { // 1m23s456ms format
+		refLap = int(util.ParseLaptime(lap))
+	} else { // int milliseconds
+		refLap, _ = strconv.Atoi(lap)
+		refLap = refLap * 10
+	}
+	if refLap < 1 {
+		refLap = 0
+	}
+	refName := req.URL.Query().Get("reference")
+	if len(refName) == 0 {
+		refName = "Reference"
+	}
+	return database.Laptime(refLap), refName
+}
+
+// getLocation reads the optional IANA timezone from the "tz" query parameter, defaults to UTC
+func getLocation(req *http.Request) (*time.Location, error) {
+	tz := req.URL.Query().Get("tz")
+	if len(tz) == 0 {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// getEnrichment reads the optional trackConfig, trackMap and carLogos query parameters
+func getEnrichment(req *http.Request) (image.Enrichment, error) {
+	var enrichment image.Enrichment
+	for param, option := range map[string]*bool{
+		"trackConfig": &enrichment.TrackConfig,
+		"trackMap":    &enrichment.TrackMap,
+		"carLogos":    &enrichment.CarLogos,
+	} {
+		value := req.URL.Query().Get(param)
+		if len(value) > 0 {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				log.Errorf("could not convert %s [%s] to bool: %v", param, value, err)
+				return enrichment, err
+			}
+			*option = enabled
+		}
+	}
+	return enrichment, nil
+}
+
+// getRules reads the optional "rules" query parameter, a scoring rules preset, and returns its image file variants.
+// There are no variants if the default preset is used
+func getRules(req *http.Request, defaultPreset string) (scoring.Rules, []string, error) {
+	value := req.URL.Query().Get("rules")
+	if len(value) == 0 {
+		value = defaultPreset
+	}
+	rules, err := scoring.Get(value)
+	if err != nil {
+		log.Errorf("could not get rules [%s]: %v", value, err)
+		return nil, nil, err
+	}
+	if rules.Name() == defaultPreset {
+		return rules, []string{}, nil
+	}
+	return rules, []string{"rules_" + rules.Name()}, nil
+}